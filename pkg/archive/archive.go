@@ -0,0 +1,315 @@
+// Package archive keeps every distinct forecast the plugin has fetched so
+// danger trends can be queried over time
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const fileName = "forecasts.jsonl"
+
+// maxLine is far longer than any archived forecast, so longer lines are
+// skipped without being read into memory
+const maxLine = 64 * 1024
+
+// Forecast is what the archive keeps of a region's forecast
+type Forecast struct {
+	Region        caic.Region `json:"region"`
+	Issued        time.Time   `json:"issued"`
+	Expires       time.Time   `json:"expires"`
+	Rating        int         `json:"rating"`
+	AboveTreeline int         `json:"aboveTreeline"`
+	NearTreeline  int         `json:"nearTreeline"`
+	BelowTreeline int         `json:"belowTreeline"`
+
+	// The aspect rose, which is fetched separately from the ratings
+	AboveTreelineAspects caic.OrdinalDanger `json:"aboveTreelineAspects"`
+	NearTreelineAspects  caic.OrdinalDanger `json:"nearTreelineAspects"`
+	BelowTreelineAspects caic.OrdinalDanger `json:"belowTreelineAspects"`
+}
+
+// A forecast is identified by its region and when it was issued
+type key struct {
+	region caic.Region
+	issued int64
+}
+
+func keyFor(f Forecast) key {
+	return key{f.Region, f.Issued.Unix()}
+}
+
+// Archive is an append only file of forecasts, one JSON object per line.
+// A forecast that changes is appended again and the last line wins. Once
+// most of the lines have been replaced the file is compacted, so it only
+// grows with the number of distinct forecasts.
+type Archive struct {
+	m         sync.Mutex
+	path      string
+	forecasts map[key]Forecast
+	lines     int // in the file, including replaced and unreadable ones
+}
+
+// Open loads the archive in dir, creating it if it doesn't exist. Lines
+// that can't be read, like one cut short by a crash or one too long to be
+// a forecast, are skipped.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		path:      filepath.Join(dir, fileName),
+		forecasts: make(map[key]Forecast),
+	}
+
+	f, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	skipped, err := a.load(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// A line cut short would run into the next one appended after it
+	if skipped > 0 {
+		err = a.compact()
+	} else {
+		err = a.compactIfReplaced()
+	}
+	if err != nil {
+		log.DefaultLogger.Warn("unable to compact the forecast archive", "error", err)
+	}
+	return a, nil
+}
+
+// load reads the archived forecasts from r, returning how many lines
+// couldn't be read
+func (a *Archive) load(r io.Reader) (int, error) {
+	skipped := 0
+	br := bufio.NewReaderSize(r, maxLine)
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			log.DefaultLogger.Warn("skipping archived forecast longer than the longest line", "maxLine", maxLine)
+			for err == bufio.ErrBufferFull {
+				_, err = br.ReadSlice('\n')
+			}
+			a.lines++
+			skipped++
+			line = nil
+		}
+		if err != nil && err != io.EOF {
+			return skipped, err
+		}
+
+		if len(line) > 0 {
+			a.lines++
+
+			var fc Forecast
+			if jsonErr := json.Unmarshal(line, &fc); jsonErr != nil {
+				log.DefaultLogger.Warn("skipping unreadable archived forecast", "error", jsonErr)
+				skipped++
+			} else {
+				a.forecasts[keyFor(fc)] = fc
+			}
+		}
+
+		if err == io.EOF {
+			return skipped, nil
+		}
+	}
+}
+
+// AddZones archives the ratings of the zones. Zones without an issue time
+// can't be told apart and are skipped.
+func (a *Archive) AddZones(zones []caic.Zone) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	var changed []Forecast
+	for _, z := range zones {
+		f, ok := a.merge(z.Index, z.Issued, z.Expires, func(f *Forecast) {
+			f.Rating = z.Rating
+			f.AboveTreeline = z.AboveTreeline
+			f.NearTreeline = z.NearTreeline
+			f.BelowTreeline = z.BelowTreeline
+		})
+		if ok {
+			changed = append(changed, f)
+		}
+	}
+	return a.append(changed)
+}
+
+// AddAspectDanger archives the aspect rose of a forecast
+func (a *Archive) AddAspectDanger(ad caic.AspectDanger) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	f, ok := a.merge(ad.Region, ad.Issued, ad.Expires, func(f *Forecast) {
+		f.AboveTreelineAspects = ad.AboveTreeline
+		f.NearTreelineAspects = ad.NearTreeline
+		f.BelowTreelineAspects = ad.BelowTreeline
+	})
+	if !ok {
+		return nil
+	}
+	return a.append([]Forecast{f})
+}
+
+// merge sets part of an archived forecast, returning it and whether it
+// changed. a.m must be held.
+func (a *Archive) merge(r caic.Region, issued, expires time.Time, set func(*Forecast)) (Forecast, bool) {
+	if issued.IsZero() || r == caic.EntireState {
+		return Forecast{}, false
+	}
+
+	// Times are kept in UTC so they compare the same after a round trip
+	// through the file
+	f := Forecast{Region: r, Issued: issued.UTC()}
+	k := keyFor(f)
+	if old, ok := a.forecasts[k]; ok {
+		f = old
+	}
+	f.Expires = expires.UTC()
+	set(&f)
+
+	if f == a.forecasts[k] {
+		return f, false
+	}
+	a.forecasts[k] = f
+	return f, true
+}
+
+// append writes the forecasts to the end of the file. a.m must be held.
+func (a *Archive) append(forecasts []Forecast) error {
+	if len(forecasts) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f, forecasts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	a.lines += len(forecasts)
+	return a.compactIfReplaced()
+}
+
+// compactIfReplaced rewrites the file with a line for each forecast once
+// more than half of its lines have been replaced. a.m must be held.
+func (a *Archive) compactIfReplaced() error {
+	if a.lines <= 2*len(a.forecasts) {
+		return nil
+	}
+	return a.compact()
+}
+
+// compact rewrites the file with a line for each forecast. a.m must be
+// held, or the archive not yet shared.
+func (a *Archive) compact() error {
+	forecasts := make([]Forecast, 0, len(a.forecasts))
+	for _, f := range a.forecasts {
+		forecasts = append(forecasts, f)
+	}
+	sortByIssued(forecasts)
+
+	f, err := os.CreateTemp(filepath.Dir(a.path), ".forecasts-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := write(f, forecasts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), a.path); err != nil {
+		return err
+	}
+
+	a.lines = len(forecasts)
+	return nil
+}
+
+// write encodes the forecasts to w a line each
+func write(w io.Writer, forecasts []Forecast) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, fc := range forecasts {
+		if err := enc.Encode(fc); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// History returns the forecast for the region in effect at from and the
+// ones issued after it until to, ordered by when they were issued.
+// For EntireState it returns the history of every region.
+func (a *Archive) History(r caic.Region, from, to time.Time) []Forecast {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	var history []Forecast
+	inEffect := make(map[caic.Region]Forecast)
+	for _, f := range a.forecasts {
+		if r != caic.EntireState && f.Region != r {
+			continue
+		}
+
+		switch {
+		case f.Issued.After(to):
+		case !f.Issued.After(from):
+			if f.Issued.After(inEffect[f.Region].Issued) {
+				inEffect[f.Region] = f
+			}
+		default:
+			history = append(history, f)
+		}
+	}
+
+	for _, f := range inEffect {
+		history = append(history, f)
+	}
+
+	sortByIssued(history)
+	return history
+}
+
+// sortByIssued orders forecasts by when they were issued, then by region
+func sortByIssued(forecasts []Forecast) {
+	sort.Slice(forecasts, func(i, j int) bool {
+		if forecasts[i].Issued.Equal(forecasts[j].Issued) {
+			return forecasts[i].Region < forecasts[j].Region
+		}
+		return forecasts[i].Issued.Before(forecasts[j].Issued)
+	})
+}
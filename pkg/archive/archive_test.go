@@ -0,0 +1,209 @@
+package archive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/archive"
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+var day1 = time.Date(2021, 4, 14, 16, 30, 0, 0, time.UTC)
+
+func issuedOn(day int) time.Time {
+	return day1.AddDate(0, 0, day)
+}
+
+func TestHistory(t *testing.T) {
+	t.Run("it returns the forecasts in the range and the one in effect at its start", func(t *testing.T) {
+		a, err := archive.Open(t.TempDir())
+		require.Nil(t, err)
+
+		for day := 0; day < 5; day++ {
+			require.Nil(t, a.AddZones([]caic.Zone{
+				{Index: caic.FrontRange, Rating: day + 1, Issued: issuedOn(day), Expires: issuedOn(day + 1)},
+				{Index: caic.Aspen, Rating: day, Issued: issuedOn(day), Expires: issuedOn(day + 1)},
+			}))
+		}
+
+		history := a.History(caic.FrontRange, issuedOn(1).Add(time.Hour), issuedOn(3))
+		require.Len(t, history, 3)
+		require.Equal(t, []int{2, 3, 4}, []int{history[0].Rating, history[1].Rating, history[2].Rating})
+		require.True(t, issuedOn(1).Equal(history[0].Issued))
+
+		state := a.History(caic.EntireState, issuedOn(4), issuedOn(5))
+		require.Len(t, state, 2)
+		require.Equal(t, caic.FrontRange, state[0].Region)
+		require.Equal(t, caic.Aspen, state[1].Region)
+	})
+
+	t.Run("it merges the ratings and rose of a forecast", func(t *testing.T) {
+		a, err := archive.Open(t.TempDir())
+		require.Nil(t, err)
+
+		require.Nil(t, a.AddZones([]caic.Zone{{Index: caic.FrontRange, Rating: 3, Issued: day1}}))
+		require.Nil(t, a.AddAspectDanger(caic.AspectDanger{
+			Region:        caic.FrontRange,
+			AboveTreeline: caic.OrdinalDanger{North: true},
+			Issued:        day1,
+		}))
+
+		history := a.History(caic.FrontRange, day1, day1)
+		require.Len(t, history, 1)
+		require.Equal(t, 3, history[0].Rating)
+		require.True(t, history[0].AboveTreelineAspects.North)
+	})
+
+	t.Run("forecasts without an issue time aren't archived", func(t *testing.T) {
+		a, err := archive.Open(t.TempDir())
+		require.Nil(t, err)
+
+		require.Nil(t, a.AddZones([]caic.Zone{{Index: caic.FrontRange, Rating: 3}}))
+		require.Empty(t, a.History(caic.EntireState, time.Time{}, time.Now()))
+	})
+}
+
+func TestArchiveFile(t *testing.T) {
+	t.Run("forecasts survive reopening the archive", func(t *testing.T) {
+		dir := t.TempDir()
+		a, err := archive.Open(dir)
+		require.Nil(t, err)
+		require.Nil(t, a.AddZones([]caic.Zone{{Index: caic.FrontRange, Rating: 3, Issued: day1}}))
+
+		a, err = archive.Open(dir)
+		require.Nil(t, err)
+
+		history := a.History(caic.FrontRange, day1, day1)
+		require.Len(t, history, 1)
+		require.Equal(t, 3, history[0].Rating)
+	})
+
+	t.Run("a forecast is only written again when it changes", func(t *testing.T) {
+		dir := t.TempDir()
+		a, err := archive.Open(dir)
+		require.Nil(t, err)
+
+		zones := []caic.Zone{{Index: caic.FrontRange, Rating: 3, Issued: day1}}
+		require.Nil(t, a.AddZones(zones))
+		require.Nil(t, a.AddZones(zones))
+
+		a, err = archive.Open(dir)
+		require.Nil(t, err)
+		require.Nil(t, a.AddZones(zones))
+
+		b, err := os.ReadFile(filepath.Join(dir, "forecasts.jsonl"))
+		require.Nil(t, err)
+		require.Equal(t, 1, bytes.Count(b, []byte("\n")))
+	})
+
+	t.Run("unreadable lines are skipped", func(t *testing.T) {
+		dir := t.TempDir()
+		a, err := archive.Open(dir)
+		require.Nil(t, err)
+		require.Nil(t, a.AddZones([]caic.Zone{{Index: caic.FrontRange, Rating: 3, Issued: day1}}))
+
+		f, err := os.OpenFile(filepath.Join(dir, "forecasts.jsonl"), os.O_APPEND|os.O_WRONLY, 0o644)
+		require.Nil(t, err)
+		_, err = f.WriteString(`{"region": 1, "iss`)
+		require.Nil(t, err)
+		require.Nil(t, f.Close())
+
+		a, err = archive.Open(dir)
+		require.Nil(t, err)
+		require.Len(t, a.History(caic.EntireState, day1, day1), 1)
+
+		// The cut short line doesn't swallow the next one
+		require.Nil(t, a.AddZones([]caic.Zone{{Index: caic.Aspen, Rating: 2, Issued: day1}}))
+		a, err = archive.Open(dir)
+		require.Nil(t, err)
+		require.Len(t, a.History(caic.EntireState, day1, day1), 2)
+	})
+
+	t.Run("lines too long to be a forecast are skipped", func(t *testing.T) {
+		dir := t.TempDir()
+		line := `{"region": 1, "padding": "` + strings.Repeat("x", 128*1024) + "\"}\n"
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "forecasts.jsonl"), []byte(line), 0o644))
+
+		a, err := archive.Open(dir)
+		require.Nil(t, err)
+		require.Nil(t, a.AddZones([]caic.Zone{{Index: caic.FrontRange, Rating: 3, Issued: day1}}))
+
+		a, err = archive.Open(dir)
+		require.Nil(t, err)
+		require.Len(t, a.History(caic.EntireState, day1, day1), 1)
+	})
+
+	t.Run("the file is compacted once most of it has been replaced", func(t *testing.T) {
+		dir := t.TempDir()
+		a, err := archive.Open(dir)
+		require.Nil(t, err)
+
+		for rating := 1; rating <= 5; rating++ {
+			require.Nil(t, a.AddZones([]caic.Zone{{Index: caic.FrontRange, Rating: rating, Issued: day1}}))
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, "forecasts.jsonl"))
+		require.Nil(t, err)
+		require.LessOrEqual(t, bytes.Count(b, []byte("\n")), 2)
+
+		a, err = archive.Open(dir)
+		require.Nil(t, err)
+		history := a.History(caic.FrontRange, day1, day1)
+		require.Len(t, history, 1)
+		require.Equal(t, 5, history[0].Rating)
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("it archives the forecasts it fetches", func(t *testing.T) {
+		a, err := archive.Open(t.TempDir())
+		require.Nil(t, err)
+
+		source := &fakeSource{forecast: caic.Forecast{
+			Region: caic.FrontRange,
+			Zones:  []caic.Zone{{Index: caic.FrontRange, Rating: 3, Issued: day1}},
+			Errors: map[string]*caic.ParseError{caic.AspectDangerPart: {Region: caic.FrontRange}},
+		}}
+		r := archive.NewRecorder(source, a)
+
+		f, err := r.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, source.forecast, f)
+
+		history := a.History(caic.EntireState, day1, day1)
+		require.Len(t, history, 1)
+		require.Equal(t, 3, history[0].Rating)
+	})
+
+	t.Run("it doesn't archive forecasts that failed", func(t *testing.T) {
+		a, err := archive.Open(t.TempDir())
+		require.Nil(t, err)
+
+		source := &fakeSource{err: errors.New("boom")}
+		r := archive.NewRecorder(source, a)
+
+		_, err = r.Forecast(context.Background(), caic.FrontRange)
+		require.EqualError(t, err, "boom")
+		require.Empty(t, a.History(caic.EntireState, day1, day1))
+	})
+}
+
+type fakeSource struct {
+	forecast caic.Forecast
+	err      error
+}
+
+func (s *fakeSource) CanConnect(context.Context) bool {
+	return true
+}
+
+func (s *fakeSource) Forecast(context.Context, caic.Region) (caic.Forecast, error) {
+	return s.forecast, s.err
+}
@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Recorder archives the forecasts its source fetches. It sits between the
+// cache and the source so each forecast is only recorded when it's fetched.
+type Recorder struct {
+	source  caic.Source
+	archive *Archive
+}
+
+func NewRecorder(s caic.Source, a *Archive) *Recorder {
+	return &Recorder{
+		source:  s,
+		archive: a,
+	}
+}
+
+func (r *Recorder) CanConnect(ctx context.Context) bool {
+	return r.source.CanConnect(ctx)
+}
+
+// Forecast archives the zones and aspect danger that could be read from
+// the forecast
+func (r *Recorder) Forecast(ctx context.Context, region caic.Region) (caic.Forecast, error) {
+	f, err := r.source.Forecast(ctx, region)
+	if err != nil {
+		return f, err
+	}
+
+	if len(f.Zones) > 0 {
+		r.record(r.archive.AddZones(f.Zones))
+	}
+	if f.Err(caic.AspectDangerPart, nil) == nil {
+		r.record(r.archive.AddAspectDanger(f.AspectDanger))
+	}
+	return f, nil
+}
+
+// record logs archive failures rather than failing the query that
+// fetched the forecast
+func (r *Recorder) record(err error) {
+	if err != nil {
+		log.DefaultLogger.Warn("unable to archive forecast", "error", err)
+	}
+}
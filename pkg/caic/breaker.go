@@ -0,0 +1,101 @@
+package caic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitOpenError is returned without making a request while the circuit
+// breaker is giving CAIC time to recover
+type CircuitOpenError struct {
+	Until time.Time // when requests are tried again
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprint("not requesting CAIC after repeated failures until ", e.Until.Format(time.RFC3339))
+}
+
+type circuitBreaker struct {
+	d         Doer
+	threshold int
+	coolDown  time.Duration
+
+	m        sync.Mutex
+	failures int       // consecutive failures
+	openedAt time.Time // zero while the circuit is closed
+	trial    bool      // whether a request is testing the half open circuit
+}
+
+// NewCircuitBreakerDoer returns a Doer that stops making requests for the
+// cool-down once threshold requests in a row have failed. After the
+// cool-down one request is let through: the circuit closes again if it
+// works, and stays open for another cool-down if it doesn't. Requests
+// fail when they can't be sent or get a 5xx or 429 status.
+func NewCircuitBreakerDoer(d Doer, threshold int, coolDown time.Duration) Doer {
+	return &circuitBreaker{
+		d:         d,
+		threshold: threshold,
+		coolDown:  coolDown,
+	}
+}
+
+func (b *circuitBreaker) Do(req *http.Request) (*http.Response, error) {
+	trial, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.d.Do(req)
+	b.record(req.Context(), trial, resp, err)
+	return resp, err
+}
+
+// allow returns an error unless the circuit is closed or the request can
+// test the half open circuit, and whether the request is that test
+func (b *circuitBreaker) allow() (bool, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.openedAt.IsZero() {
+		return false, nil
+	}
+
+	until := b.openedAt.Add(b.coolDown)
+	if time.Now().Before(until) || b.trial {
+		return false, &CircuitOpenError{Until: until}
+	}
+	b.trial = true
+	return true, nil
+}
+
+// record counts how the request went. Only the trial request ends the
+// trial, requests that were already in flight when the circuit opened
+// don't.
+func (b *circuitBreaker) record(ctx context.Context, trial bool, resp *http.Response, err error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if trial {
+		b.trial = false
+	}
+
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		// The caller gave up, which says nothing about CAIC
+		return
+	}
+
+	if err == nil && resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if trial || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
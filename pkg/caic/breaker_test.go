@@ -0,0 +1,173 @@
+package caic_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	// breakerServer answers with the status in code, counting requests
+	breakerServer := func(code *int32) (caic.Doer, *int32) {
+		var requests int32
+		return doerFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requests, 1)
+			return &http.Response{StatusCode: int(atomic.LoadInt32(code)), Body: http.NoBody}, nil
+		}), &requests
+	}
+
+	get := func(d caic.Doer) (*http.Response, error) {
+		return doRequest(t, context.Background(), d, http.MethodGet, "http://caic.test")
+	}
+
+	t.Run("it stops requests after repeated failures", func(t *testing.T) {
+		code := int32(http.StatusServiceUnavailable)
+		d, requests := breakerServer(&code)
+		b := caic.NewCircuitBreakerDoer(d, 3, time.Hour)
+
+		for i := 0; i < 3; i++ {
+			resp, err := get(b)
+			require.Nil(t, err)
+			require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		}
+
+		_, err := get(b)
+		var openErr *caic.CircuitOpenError
+		require.True(t, errors.As(err, &openErr))
+		require.True(t, openErr.Until.After(time.Now()))
+		require.Equal(t, int32(3), atomic.LoadInt32(requests))
+	})
+
+	t.Run("successes reset the failure count", func(t *testing.T) {
+		code := int32(http.StatusServiceUnavailable)
+		d, requests := breakerServer(&code)
+		b := caic.NewCircuitBreakerDoer(d, 2, time.Hour)
+
+		_, _ = get(b)
+		atomic.StoreInt32(&code, http.StatusOK)
+		_, _ = get(b)
+		atomic.StoreInt32(&code, http.StatusServiceUnavailable)
+		_, _ = get(b)
+
+		_, err := get(b)
+		require.Nil(t, err)
+		require.Equal(t, int32(4), atomic.LoadInt32(requests))
+	})
+
+	t.Run("statuses that aren't CAIC's fault aren't failures", func(t *testing.T) {
+		code := int32(http.StatusNotFound)
+		d, requests := breakerServer(&code)
+		b := caic.NewCircuitBreakerDoer(d, 1, time.Hour)
+
+		_, _ = get(b)
+		_, err := get(b)
+		require.Nil(t, err)
+		require.Equal(t, int32(2), atomic.LoadInt32(requests))
+	})
+
+	t.Run("it tries one request after the cool-down", func(t *testing.T) {
+		code := int32(http.StatusBadGateway)
+		d, requests := breakerServer(&code)
+		b := caic.NewCircuitBreakerDoer(d, 1, 20*time.Millisecond)
+
+		_, _ = get(b)
+		_, err := get(b)
+		require.NotNil(t, err)
+
+		// The trial fails, so the circuit opens for another cool-down
+		time.Sleep(30 * time.Millisecond)
+		resp, err := get(b)
+		require.Nil(t, err)
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		_, err = get(b)
+		require.NotNil(t, err)
+		require.Equal(t, int32(2), atomic.LoadInt32(requests))
+
+		// The trial works, so the circuit closes
+		atomic.StoreInt32(&code, http.StatusOK)
+		time.Sleep(30 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			_, err = get(b)
+			require.Nil(t, err)
+		}
+		require.Equal(t, int32(5), atomic.LoadInt32(requests))
+	})
+
+	t.Run("only the trial request ends the trial", func(t *testing.T) {
+		code := int32(http.StatusOK)
+		var blocking int32 = 1
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		d := doerFunc(func(req *http.Request) (*http.Response, error) {
+			if atomic.LoadInt32(&blocking) == 1 {
+				started <- struct{}{}
+				select {
+				case <-release:
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+			}
+			return &http.Response{StatusCode: int(atomic.LoadInt32(&code)), Body: http.NoBody}, nil
+		})
+		b := caic.NewCircuitBreakerDoer(d, 1, 20*time.Millisecond)
+
+		// A request is in flight when another one opens the circuit
+		ctx, cancel := context.WithCancel(context.Background())
+		inFlight := make(chan error)
+		go func() {
+			_, err := doRequest(t, ctx, b, http.MethodGet, "http://caic.test")
+			inFlight <- err
+		}()
+		<-started
+		atomic.StoreInt32(&blocking, 0)
+		atomic.StoreInt32(&code, http.StatusBadGateway)
+		_, _ = get(b)
+
+		time.Sleep(30 * time.Millisecond)
+		atomic.StoreInt32(&code, http.StatusOK)
+		atomic.StoreInt32(&blocking, 1)
+		trial := make(chan error)
+		go func() {
+			_, err := get(b)
+			trial <- err
+		}()
+		<-started
+		atomic.StoreInt32(&blocking, 0)
+
+		cancel()
+		require.Equal(t, context.Canceled, <-inFlight)
+
+		_, err := get(b)
+		var openErr *caic.CircuitOpenError
+		require.True(t, errors.As(err, &openErr), "a second trial was let through")
+
+		close(release)
+		require.Nil(t, <-trial)
+		_, err = get(b)
+		require.Nil(t, err)
+	})
+
+	t.Run("requests the caller gave up on aren't failures", func(t *testing.T) {
+		d := doerFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+		b := caic.NewCircuitBreakerDoer(d, 1, time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := doRequest(t, ctx, b, http.MethodGet, "http://caic.test")
+		require.Equal(t, context.Canceled, err)
+
+		ctx, cancel = context.WithCancel(context.Background())
+		cancel()
+		_, err = doRequest(t, ctx, b, http.MethodGet, "http://caic.test")
+		require.Equal(t, context.Canceled, err, "the circuit is still closed")
+	})
+}
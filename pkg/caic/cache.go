@@ -1,51 +1,140 @@
 package caic
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
 
-type client interface {
-	CanConnect() bool
-	Summary(Region) ([]Zone, error)
-	AspectDanger(Region) (AspectDanger, error)
+// Fetcher fetches each part of a forecast. The Cache is the Fetcher, it
+// serves every part from the whole forecasts a Source fetches.
+type Fetcher interface {
+	CanConnect(context.Context) bool
+	Summary(context.Context, Region) ([]Zone, error)
+	AspectDanger(context.Context, Region) (AspectDanger, error)
+	Problems(context.Context, Region) ([]AvalancheProblem, error)
+	ForecastText(context.Context, Region) (ForecastText, error)
+}
+
+// The kind of data the cache holds for each region: the whole forecast
+// read from its page, which every part is served from
+const forecastKind = "forecast"
+
+type cacheKey struct {
+	kind   string
+	region Region
 }
 
-type zone struct {
-	t time.Time
-	z []Zone
+type entry struct {
+	fetched time.Time
+	expires time.Time
+	value   interface{}
+	err     error // why the last background refresh failed
 }
 
-type aspectDanger struct {
-	t  time.Time
-	ad AspectDanger
+// refreshAt is when the entry is refreshed in the background, a quarter of
+// its lifetime before it expires
+func (e entry) refreshAt() time.Time {
+	return e.expires.Add(-e.expires.Sub(e.fetched) / 4)
+}
+
+type fetchFunc func(context.Context) (interface{}, error)
+
+// StaleError is returned along with cached data that is past its cache
+// duration because it hasn't been refreshed yet, usually because CAIC is
+// unreachable
+type StaleError struct {
+	Fetched time.Time
+	Err     error // the last refresh error, if any
 }
 
+func (e *StaleError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprint("data fetched at ", e.Fetched.Format(time.RFC3339), " is stale")
+	}
+	return fmt.Sprint("data fetched at ", e.Fetched.Format(time.RFC3339), " is stale: ", e.Err)
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// worse is whether e says more about what's wrong than other: a failed
+// refresh over one that hasn't finished, then the older data
+func (e *StaleError) worse(other *StaleError) bool {
+	if (e.Err != nil) != (other.Err != nil) {
+		return e.Err != nil
+	}
+	return e.Fetched.Before(other.Fetched)
+}
+
+// Cache serves the last good forecast for each region and refreshes it in
+// the background before it expires. Forecasts expire when they're
+// replaced, according to the Schedule, or after the cache duration. Once
+// a forecast has expired it is still served, with a *StaleError, until it
+// can be refreshed. Every part of a forecast is served from the one cached
+// forecast, so the region's page is only fetched once for all of them.
+// The zones and problems for the entire state are put together from the
+// forecast for every region.
+//
+// Fetches are per key: concurrent callers missing the same key share one
+// upstream fetch, and fetches for different keys run in parallel.
 type Cache struct {
-	m                 sync.Mutex
-	client            client
-	regionCache       map[string]zone
-	aspectDangerCache map[string]aspectDanger
-	cacheDuration     time.Duration
+	m              sync.Mutex // guards entries and calls, never held during a fetch
+	source         Source
+	entries        map[cacheKey]entry
+	calls          map[cacheKey]*call
+	cacheDuration  time.Duration
+	refreshTimeout time.Duration
+	schedule       Schedule
+	store          *store
+	now            func() time.Time
+}
+
+// call is an upstream fetch shared by everyone waiting on its key
+type call struct {
+	done    chan struct{}
+	value   interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
 }
 
-func NewClientCache(c client, opts ...CacheOption) *Cache {
+func NewClientCache(s Source, opts ...CacheOption) *Cache {
 	cache := &Cache{
-		client:            c,
-		regionCache:       make(map[string]zone),
-		aspectDangerCache: make(map[string]aspectDanger),
-		cacheDuration:     time.Hour,
+		source:         s,
+		entries:        make(map[cacheKey]entry),
+		calls:          make(map[cacheKey]*call),
+		cacheDuration:  time.Hour,
+		refreshTimeout: 30 * time.Second,
+		schedule:       DefaultSchedule,
+		now:            time.Now,
 	}
 
 	for _, o := range opts {
 		o(cache)
 	}
 
+	if cache.store != nil {
+		entries, errs := cache.store.load()
+		for _, err := range errs {
+			log.DefaultLogger.Warn("ignoring cached forecast", "error", err)
+		}
+		cache.entries = entries
+	}
+
 	return cache
 }
 
 type CacheOption func(c *Cache)
 
+// WithCacheDuration caps how long a value is cached, however long its
+// forecast is good for, so mid-day updates to a forecast are picked up
 func WithCacheDuration(d time.Duration) CacheOption {
 	return func(c *Cache) {
 		c.cacheDuration = d
@@ -53,50 +142,341 @@ func WithCacheDuration(d time.Duration) CacheOption {
 
 }
 
-func (c *Cache) Summary(r Region) ([]Zone, error) {
+// WithSchedule sets when CAIC publishes forecasts
+func WithSchedule(s Schedule) CacheOption {
+	return func(c *Cache) {
+		c.schedule = s
+	}
+}
+
+// WithRefreshTimeout limits how long an upstream fetch can take
+func WithRefreshTimeout(d time.Duration) CacheOption {
+	return func(c *Cache) {
+		if d > 0 {
+			c.refreshTimeout = d
+		}
+	}
+}
+
+// WithClock sets the clock values are refreshed and expired by
+func WithClock(now func() time.Time) CacheOption {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
+
+// WithStore keeps the cache in dir as well as in memory so it survives
+// restarts. Entries in dir are loaded when the cache is created.
+func WithStore(dir string) CacheOption {
+	return func(c *Cache) {
+		c.store = &store{dir: dir}
+	}
+}
+
+// Summary returns the cached zones for the region. Partial results are
+// returned with their error.
+func (c *Cache) Summary(ctx context.Context, r Region) ([]Zone, error) {
+	if r == EntireState {
+		var zones []Zone
+		err := c.eachRegion(ctx, SummaryPart, func(f Forecast) {
+			zones = append(zones, f.Zones...)
+		})
+		return zones, err
+	}
+
+	f, err := c.forecast(ctx, r)
+	return f.Zones, f.Err(SummaryPart, err)
+}
+
+func (c *Cache) AspectDanger(ctx context.Context, r Region) (AspectDanger, error) {
+	f, err := c.forecast(ctx, r)
+	return f.AspectDanger, f.Err(AspectDangerPart, err)
+}
+
+func (c *Cache) Problems(ctx context.Context, r Region) ([]AvalancheProblem, error) {
+	if r == EntireState {
+		var problems []AvalancheProblem
+		err := c.eachRegion(ctx, ProblemsPart, func(f Forecast) {
+			problems = append(problems, f.Problems...)
+		})
+		return problems, err
+	}
+
+	f, err := c.forecast(ctx, r)
+	return f.Problems, f.Err(ProblemsPart, err)
+}
+
+func (c *Cache) ForecastText(ctx context.Context, r Region) (ForecastText, error) {
+	f, err := c.forecast(ctx, r)
+	return f.Text, f.Err(TextPart, err)
+}
+
+func (c *Cache) CanConnect(ctx context.Context) bool {
+	return c.source.CanConnect(ctx)
+}
+
+// forecast returns the cached forecast for the region. Forecasts that
+// couldn't be fetched are never cached, but parts that couldn't be read
+// from a page that was are cached with it until the page is fetched
+// again.
+func (c *Cache) forecast(ctx context.Context, r Region) (Forecast, error) {
+	v, err := c.get(ctx, cacheKey{forecastKind, r}, func(ctx context.Context) (interface{}, error) {
+		return c.source.Forecast(ctx, r)
+	})
+	f, _ := v.(Forecast)
+	return f, err
+}
+
+// eachRegion calls fn, in region order, with the forecast for every region
+// in the state that has the part. The regions are fetched in parallel.
+// Regions without the part are returned as a *PartialError, unless none of
+// them have it, and stale regions as the oldest *StaleError when nothing
+// failed, preferring one whose refresh failed.
+func (c *Cache) eachRegion(ctx context.Context, part string, fn func(Forecast)) error {
+	forecasts := make([]Forecast, regionCount)
+	errs := make([]error, regionCount)
+
+	var wg sync.WaitGroup
+	for r := SteamboatFlatTops; r <= SangreDeCristo; r++ {
+		wg.Add(1)
+		go func(r Region) {
+			defer wg.Done()
+			f, err := c.forecast(ctx, r)
+			forecasts[r], errs[r] = f, f.Err(part, err)
+		}(r)
+	}
+	wg.Wait()
+
+	var failed, stale []RegionError
+	var oldest *StaleError
+	for r, err := range errs {
+		var staleErr *StaleError
+		switch {
+		case err == nil:
+		case errors.As(err, &staleErr):
+			stale = append(stale, RegionError{Region: Region(r), Err: err})
+			if oldest == nil || staleErr.worse(oldest) {
+				oldest = staleErr
+			}
+		default:
+			failed = append(failed, RegionError{Region: Region(r), Err: err})
+			continue
+		}
+		fn(forecasts[r])
+	}
+
+	if len(failed) == regionCount {
+		return failed[0].Err
+	}
+	if len(failed) > 0 {
+		// Stale regions are still worth a warning next to the failures
+		return &PartialError{Errors: append(failed, stale...)}
+	}
+	if oldest != nil {
+		return oldest
+	}
+	return nil
+}
+
+// EntryStatus describes what the cache holds for a region
+type EntryStatus struct {
+	Kind       string    `json:"kind"`
+	Region     Region    `json:"region"`
+	Fetched    time.Time `json:"fetched"`
+	Expires    time.Time `json:"expires"`
+	Stale      bool      `json:"stale"`
+	Refreshing bool      `json:"refreshing"`
+	Error      string    `json:"error,omitempty"` // why the last refresh failed
+}
+
+// Status describes every cached value and fetch in flight, ordered by
+// kind and region
+func (c *Cache) Status() []EntryStatus {
 	c.m.Lock()
 	defer c.m.Unlock()
 
-	cached, ok := c.regionCache[r.String()]
-	if ok && time.Since(cached.t) < c.cacheDuration {
-		return cached.z, nil
+	now := c.now()
+	var status []EntryStatus
+	for key, e := range c.entries {
+		s := EntryStatus{
+			Kind:       key.kind,
+			Region:     key.region,
+			Fetched:    e.fetched,
+			Expires:    e.expires,
+			Stale:      !now.Before(e.expires),
+			Refreshing: c.calls[key] != nil,
+		}
+		if e.err != nil {
+			s.Error = e.err.Error()
+		}
+		status = append(status, s)
+	}
+
+	// Keys being fetched for the first time
+	for key := range c.calls {
+		if _, ok := c.entries[key]; !ok {
+			status = append(status, EntryStatus{Kind: key.kind, Region: key.region, Refreshing: true})
+		}
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Kind == status[j].Kind {
+			return status[i].Region < status[j].Region
+		}
+		return status[i].Kind < status[j].Kind
+	})
+	return status
+}
+
+// get returns the cached value for the key, fetching it when there isn't
+// one. Values due for a refresh are refreshed in the background.
+func (c *Cache) get(ctx context.Context, key cacheKey, fetch fetchFunc) (interface{}, error) {
+	e, ok := c.entry(key)
+	if !ok {
+		return c.fetch(ctx, key, fetch)
+	}
+
+	now := c.now()
+	if now.Before(e.refreshAt()) {
+		return e.value, nil
 	}
 
-	z, err := c.client.Summary(r)
-	if err != nil {
-		return nil, err
+	c.refresh(key, fetch)
+	if now.Before(e.expires) {
+		return e.value, nil
 	}
+	return e.value, &StaleError{Fetched: e.fetched, Err: e.err}
+}
+
+func (c *Cache) entry(key cacheKey) (entry, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	e, ok := c.entries[key]
+	return e, ok
+}
 
-	c.regionCache[r.String()] = zone{
-		t: time.Now(),
-		z: z,
+// fetch waits for the value of a key that isn't cached yet, joining the
+// fetch in flight for the key if there is one. Errors and partial results
+// are returned but never cached. The fetch is cancelled when every caller
+// waiting on it has given up.
+func (c *Cache) fetch(ctx context.Context, key cacheKey, fetch fetchFunc) (interface{}, error) {
+	c.m.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.m.Unlock()
+		return e.value, nil
 	}
+	cl := c.call(detached{ctx}, key, fetch)
+	cl.waiters++
+	c.m.Unlock()
 
-	return z, nil
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		c.m.Lock()
+		cl.waiters--
+		if cl.waiters == 0 {
+			// Nobody wants the result, later callers start over
+			cl.cancel()
+			c.forget(key, cl)
+		}
+		c.m.Unlock()
+		return nil, ctx.Err()
+	}
 }
 
-func (c *Cache) AspectDanger(r Region) (AspectDanger, error) {
+// refresh fetches a new value for the key in the background unless a
+// fetch is already in flight. The old value is kept when it fails.
+func (c *Cache) refresh(key cacheKey, fetch fetchFunc) {
 	c.m.Lock()
 	defer c.m.Unlock()
 
-	ad, ok := c.aspectDangerCache[r.String()]
-	if ok && time.Since(ad.t) < c.cacheDuration {
-		return ad.ad, nil
+	// The refresh never gives up, so the fetch is never cancelled
+	c.call(context.Background(), key, fetch).waiters++
+}
+
+// call returns the fetch in flight for the key, starting one with parent
+// if there isn't one. c.m must be held.
+func (c *Cache) call(parent context.Context, key cacheKey, fetch fetchFunc) *call {
+	if cl, ok := c.calls[key]; ok {
+		return cl
 	}
 
-	a, err := c.client.AspectDanger(r)
-	if err != nil {
-		return AspectDanger{}, err
+	ctx, cancel := context.WithTimeout(parent, c.refreshTimeout)
+	cl := &call{
+		done:   make(chan struct{}),
+		cancel: cancel,
 	}
+	c.calls[key] = cl
+
+	go func() {
+		v, err := fetch(ctx)
+		cancel()
+
+		c.m.Lock()
+		c.forget(key, cl)
+		var fetched entry
+		if err == nil {
+			fetched = c.newEntry(v)
+			c.entries[key] = fetched
+		} else if e, ok := c.entries[key]; ok {
+			e.err = err
+			c.entries[key] = e
+		}
+		cl.value, cl.err = v, err
+		c.m.Unlock()
+
+		if err == nil && c.store != nil {
+			if err := c.store.save(key, fetched); err != nil {
+				log.DefaultLogger.Warn("unable to save cached forecast", "error", err)
+			}
+		}
+		close(cl.done)
+	}()
+
+	return cl
+}
 
-	c.aspectDangerCache[r.String()] = aspectDanger{
-		t:  time.Now(),
-		ad: a,
+// forget removes the call from the calls in flight if it's still there.
+// c.m must be held.
+func (c *Cache) forget(key cacheKey, cl *call) {
+	if c.calls[key] == cl {
+		delete(c.calls, key)
 	}
+}
+
+// detached keeps the values of a context, like tracing, without its
+// deadline or cancellation so a shared fetch outlives the caller that
+// started it
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
 
-	return a, nil
+func (detached) Done() <-chan struct{} {
+	return nil
 }
 
-func (c *Cache) CanConnect() bool {
-	return c.client.CanConnect()
+func (detached) Err() error {
+	return nil
+}
+
+func (c *Cache) newEntry(v interface{}) entry {
+	now := c.now()
+
+	issued, forecastExpires := forecastTimesOf(v)
+	expires := c.schedule.Expires(issued, forecastExpires, now)
+	if max := now.Add(c.cacheDuration); expires.After(max) {
+		expires = max
+	}
+
+	return entry{
+		fetched: now,
+		expires: expires,
+		value:   v,
+	}
 }
@@ -1,7 +1,12 @@
 package caic_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,27 +15,40 @@ import (
 )
 
 func TestSummary(t *testing.T) {
-	t.Run("it caches responses for duration", func(t *testing.T) {
+	t.Run("it caches responses for duration then serves them stale until refreshed", func(t *testing.T) {
 		client := newFakeClient()
 		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
-		client.regionResponse <- []caic.Zone{{Name: "Zone 2"}}
 
-		cache := caic.NewClientCache(client, caic.WithCacheDuration(10*time.Millisecond))
+		clock := newFakeClock()
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Minute), caic.WithClock(clock.Now))
 
-		call, err := cache.Summary(caic.EntireState)
+		call, err := cache.Summary(context.Background(), caic.FrontRange)
 		require.Nil(t, err)
 
-		cachedCall, err := cache.Summary(caic.EntireState)
+		cachedCall, err := cache.Summary(context.Background(), caic.FrontRange)
 		require.Nil(t, err)
+		require.Equal(t, call, cachedCall)
 
-		time.Sleep(20 * time.Millisecond)
+		clock.Advance(2 * time.Minute)
+		release := client.hold()
 
-		secondCall, err := cache.Summary(caic.EntireState)
-		require.Nil(t, err)
+		staleCall, err := cache.Summary(context.Background(), caic.FrontRange)
+		var staleErr *caic.StaleError
+		require.True(t, errors.As(err, &staleErr))
+		require.Equal(t, call, staleCall)
+
+		client.regionResponse <- []caic.Zone{{Name: "Zone 2"}}
+		close(release)
+
+		var secondCall []caic.Zone
+		require.Eventually(t, func() bool {
+			secondCall, err = cache.Summary(context.Background(), caic.FrontRange)
+			return err == nil
+		}, time.Second, time.Millisecond)
 
-		require.Equal(t, call, cachedCall)
 		require.Equal(t, "Zone 1", call[0].Name)
 		require.Equal(t, "Zone 2", secondCall[0].Name)
+		require.Equal(t, 2, client.forecastCalls())
 	})
 
 	// If incorrect, this test will fail when run with go test -race
@@ -57,38 +75,521 @@ func TestSummary(t *testing.T) {
 
 		cache := caic.NewClientCache(client, caic.WithCacheDuration(10*time.Millisecond))
 
-		_, err := cache.Summary(caic.SteamboatFlatTops)
+		_, err := cache.Summary(context.Background(), caic.SteamboatFlatTops)
 		require.NotNil(t, err)
 
-		secondCall, err := cache.Summary(caic.SteamboatFlatTops)
+		secondCall, err := cache.Summary(context.Background(), caic.SteamboatFlatTops)
 		require.Nil(t, err)
 
 		require.Equal(t, "Zone 2", secondCall[0].Name)
 	})
 }
 
-func TestAspectDangerSummary(t *testing.T) {
-	t.Run("it caches responses for duration", func(t *testing.T) {
+func TestStaleWhileRevalidate(t *testing.T) {
+	t.Run("it refreshes in the background before the value expires", func(t *testing.T) {
 		client := newFakeClient()
-		client.aspectDangerResponse <- caic.AspectDanger{Region: caic.SteamboatFlatTops}
-		client.aspectDangerResponse <- caic.AspectDanger{Region: caic.SawatchRange}
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
 
-		cache := caic.NewClientCache(client, caic.WithCacheDuration(10*time.Millisecond))
+		clock := newFakeClock()
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Minute), caic.WithClock(clock.Now))
+
+		call, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, "Zone 1", call[0].Name)
+
+		// Past the refresh point but not expired
+		clock.Advance(50 * time.Second)
+		release := client.hold()
+
+		call, err = cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, "Zone 1", call[0].Name)
+
+		client.regionResponse <- []caic.Zone{{Name: "Zone 2"}}
+		close(release)
+
+		require.Eventually(t, func() bool {
+			call, err = cache.Summary(context.Background(), caic.FrontRange)
+			return err == nil && call[0].Name == "Zone 2"
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("it keeps serving stale data when refreshes fail", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+
+		clock := newFakeClock()
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Minute), caic.WithClock(clock.Now))
 
-		call, err := cache.AspectDanger(caic.SteamboatFlatTops)
+		fetched, err := cache.Summary(context.Background(), caic.FrontRange)
 		require.Nil(t, err)
 
-		cachedCall, err := cache.AspectDanger(caic.SteamboatFlatTops)
+		clock.Advance(2 * time.Minute)
+		release := client.hold()
+		client.err <- errors.New("caic is down")
+
+		_, err = cache.Summary(context.Background(), caic.FrontRange)
+		require.NotNil(t, err)
+		require.Eventually(t, func() bool {
+			return client.forecastCalls() == 2
+		}, time.Second, time.Millisecond)
+
+		// Later refreshes wait so only the failed one finishes
+		defer close(client.hold())
+		close(release)
+
+		var staleErr *caic.StaleError
+		require.Eventually(t, func() bool {
+			call, err := cache.Summary(context.Background(), caic.FrontRange)
+			require.Equal(t, fetched, call)
+			return errors.As(err, &staleErr) && staleErr.Err != nil
+		}, time.Second, time.Millisecond)
+
+		require.EqualError(t, staleErr.Err, "caic is down")
+		require.Contains(t, staleErr.Error(), "is stale: caic is down")
+	})
+
+	t.Run("it only runs one background refresh at a time", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+
+		clock := newFakeClock()
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Minute), caic.WithClock(clock.Now))
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
 		require.Nil(t, err)
 
+		clock.Advance(2 * time.Minute)
+		release := client.hold()
+		for i := 0; i < 5; i++ {
+			cache.Summary(context.Background(), caic.FrontRange)
+		}
+		close(release)
+
+		require.Eventually(t, func() bool {
+			_, err := cache.Summary(context.Background(), caic.FrontRange)
+			return err == nil
+		}, time.Second, time.Millisecond)
+		require.Equal(t, 2, client.forecastCalls())
+	})
+}
+
+func TestForecastExpiry(t *testing.T) {
+	t.Run("values expire with their forecast", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1", Issued: time.Now(), Expires: time.Now().Add(20 * time.Millisecond)}}
+
+		schedule := caic.DefaultSchedule
+		schedule.RetryInterval = time.Millisecond
+		cache := caic.NewClientCache(client, caic.WithSchedule(schedule))
+
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		client.block = make(chan struct{})
+		defer close(client.block)
+		time.Sleep(30 * time.Millisecond)
+
+		_, err = cache.Summary(context.Background(), caic.FrontRange)
+		var staleErr *caic.StaleError
+		require.True(t, errors.As(err, &staleErr))
+	})
+
+	t.Run("the cache duration caps how long values are kept", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1", Issued: time.Now(), Expires: time.Now().Add(time.Hour)}}
+
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(10*time.Millisecond))
+
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		client.block = make(chan struct{})
+		defer close(client.block)
 		time.Sleep(20 * time.Millisecond)
 
-		secondCall, err := cache.AspectDanger(caic.SteamboatFlatTops)
+		_, err = cache.Summary(context.Background(), caic.FrontRange)
+		var staleErr *caic.StaleError
+		require.True(t, errors.As(err, &staleErr))
+	})
+
+	t.Run("values are cached for at most an hour by default", func(t *testing.T) {
+		client := newFakeClient()
+
+		schedule := caic.DefaultSchedule
+		schedule.RetryInterval = 24 * time.Hour
+		cache := caic.NewClientCache(client, caic.WithSchedule(schedule))
+
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
 		require.Nil(t, err)
 
+		status := cache.Status()
+		require.Len(t, status, 1)
+		require.Equal(t, time.Hour, status[0].Expires.Sub(status[0].Fetched))
+	})
+}
+
+func TestCacheContext(t *testing.T) {
+	t.Run("it stops waiting for a fetch when the context is done", func(t *testing.T) {
+		client := newFakeClient()
+		client.block = make(chan struct{})
+		defer close(client.block)
+
+		cache := caic.NewClientCache(client)
+
+		go cache.Summary(context.Background(), caic.FrontRange)
+		time.Sleep(10 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := cache.Summary(ctx, caic.FrontRange)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("it cancels the fetch when every caller has given up", func(t *testing.T) {
+		client := newFakeClient()
+		client.block = make(chan struct{})
+		defer close(client.block)
+
+		cache := caic.NewClientCache(client)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go cache.Summary(ctx, caic.FrontRange)
+		require.Eventually(t, func() bool {
+			return client.forecastCalls() == 1
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		require.Eventually(t, func() bool {
+			return client.forecastCtx().Err() == context.Canceled
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("it passes the context to the client", func(t *testing.T) {
+		client := newFakeClient()
+		cache := caic.NewClientCache(client)
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+		cache.Problems(ctx, caic.FrontRange)
+
+		require.Equal(t, "value", client.forecastCtx().Value(ctxKey{}))
+	})
+}
+
+type ctxKey struct{}
+
+func TestRequestCoalescing(t *testing.T) {
+	t.Run("concurrent callers of a key share one fetch", func(t *testing.T) {
+		client := newFakeClient()
+		client.block = make(chan struct{})
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+
+		cache := caic.NewClientCache(client)
+
+		var wg sync.WaitGroup
+		results := make([][]caic.Zone, 5)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = cache.Summary(context.Background(), caic.FrontRange)
+			}(i)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(client.block)
+		wg.Wait()
+
+		require.Equal(t, 1, client.forecastCalls())
+		for _, r := range results {
+			require.Equal(t, "Zone 1", r[0].Name)
+		}
+	})
+
+	t.Run("a caller giving up doesn't fail the others", func(t *testing.T) {
+		client := newFakeClient()
+		client.block = make(chan struct{})
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+
+		cache := caic.NewClientCache(client)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go cache.Summary(ctx, caic.FrontRange)
+
+		result := make(chan []caic.Zone)
+		go func() {
+			z, _ := cache.Summary(context.Background(), caic.FrontRange)
+			result <- z
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		close(client.block)
+
+		require.Equal(t, "Zone 1", (<-result)[0].Name)
+		require.Equal(t, 1, client.forecastCalls())
+	})
+
+	t.Run("different regions are fetched in parallel", func(t *testing.T) {
+		client := newFakeClient()
+		client.block = make(chan struct{})
+		defer close(client.block)
+
+		cache := caic.NewClientCache(client)
+
+		go cache.Summary(context.Background(), caic.FrontRange)
+		go cache.Summary(context.Background(), caic.SawatchRange)
+
+		require.Eventually(t, func() bool {
+			return client.forecastCalls() == 2
+		}, time.Second, time.Millisecond)
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Run("cached values survive a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		issued := time.Date(2021, 12, 18, 16, 30, 0, 0, time.UTC)
+
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Index: caic.FrontRange, Name: "Zone 1", Rating: 3, Issued: issued}}
+		client.aspectDangerResponse <- caic.AspectDanger{Region: caic.FrontRange, AboveTreeline: caic.OrdinalDanger{North: true}}
+
+		cache := caic.NewClientCache(client, caic.WithStore(dir))
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		_, err = cache.AspectDanger(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		restarted := newFakeClient()
+		cache = caic.NewClientCache(restarted, caic.WithStore(dir))
+
+		zones, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, []caic.Zone{{Index: caic.FrontRange, Name: "Zone 1", Rating: 3, Issued: issued}}, zones)
+
+		ad, err := cache.AspectDanger(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.True(t, ad.AboveTreeline.North)
+
+		require.Equal(t, 0, restarted.forecastCalls())
+	})
+
+	t.Run("expired values are served stale after a restart", func(t *testing.T) {
+		dir := t.TempDir()
+
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+
+		cache := caic.NewClientCache(client, caic.WithStore(dir), caic.WithCacheDuration(time.Millisecond))
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		time.Sleep(5 * time.Millisecond)
+
+		restarted := newFakeClient()
+		release := restarted.hold()
+
+		cache = caic.NewClientCache(restarted, caic.WithStore(dir))
+		zones, err := cache.Summary(context.Background(), caic.FrontRange)
+
+		var stale *caic.StaleError
+		require.True(t, errors.As(err, &stale))
+		require.Equal(t, "Zone 1", zones[0].Name)
+
+		// Let the refresh finish writing to dir before it's removed
+		restarted.regionResponse <- []caic.Zone{{Name: "Zone 2"}}
+		close(release)
+		require.Eventually(t, func() bool {
+			b, err := os.ReadFile(filepath.Join(dir, "forecast_1.json"))
+			return err == nil && bytes.Contains(b, []byte("Zone 2"))
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("corrupt and mismatched files are ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "summary_1.json"), []byte("{not json"), 0o644))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "summary_3.json"), []byte(`{"version":0,"kind":"summary","region":3,"value":[{"Name":"Old"}]}`), 0o644))
+
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+		client.regionResponse <- []caic.Zone{{Name: "Zone 3"}}
+
+		cache := caic.NewClientCache(client, caic.WithStore(dir))
+
+		zones, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, "Zone 1", zones[0].Name)
+
+		zones, err = cache.Summary(context.Background(), caic.SawatchRange)
+		require.Nil(t, err)
+		require.Equal(t, "Zone 3", zones[0].Name)
+		require.Equal(t, 2, client.forecastCalls())
+	})
+
+	t.Run("a missing directory is created", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "caic")
+
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+
+		cache := caic.NewClientCache(client, caic.WithStore(dir))
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		files, err := os.ReadDir(dir)
+		require.Nil(t, err)
+		require.Len(t, files, 1)
+	})
+}
+
+func TestSharedForecast(t *testing.T) {
+	t.Run("every part of a region is served from one fetch", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+		client.aspectDangerResponse <- caic.AspectDanger{Region: caic.FrontRange}
+		client.problemsResponse <- []caic.AvalancheProblem{{Type: "Wind Slab"}}
+		client.textResponse <- caic.ForecastText{BottomLine: "first"}
+
+		cache := caic.NewClientCache(client)
+
+		zones, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		ad, err := cache.AspectDanger(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		problems, err := cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		text, err := cache.ForecastText(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		require.Equal(t, "Zone 1", zones[0].Name)
+		require.Equal(t, caic.FrontRange, ad.Region)
+		require.Equal(t, "Wind Slab", problems[0].Type)
+		require.Equal(t, "first", text.BottomLine)
+		require.Equal(t, 1, client.forecastCalls())
+	})
+
+	t.Run("the entire state is put together from every region", func(t *testing.T) {
+		client := newFakeClient()
+		for i := 0; i < 10; i++ {
+			client.regionResponse <- []caic.Zone{{Name: "Zone"}}
+		}
+
+		cache := caic.NewClientCache(client)
+
+		zones, err := cache.Summary(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+		require.Len(t, zones, 10)
+		require.Equal(t, 10, client.forecastCalls())
+
+		_, err = cache.Problems(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+		_, err = cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, 10, client.forecastCalls())
+
+		_, err = cache.AspectDanger(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+		require.Equal(t, 11, client.forecastCalls(), "the statewide page is a forecast of its own")
+	})
+
+	t.Run("regions of the state that fail are a PartialError", func(t *testing.T) {
+		client := newFakeClient()
+		client.err <- errors.New("something bad")
+
+		cache := caic.NewClientCache(client)
+
+		_, err := cache.Summary(context.Background(), caic.EntireState)
+		var partialErr *caic.PartialError
+		require.True(t, errors.As(err, &partialErr))
+		require.Len(t, partialErr.Errors, 1)
+		require.EqualError(t, partialErr.Errors[0].Err, "something bad")
+	})
+
+	t.Run("the state fails when every region does", func(t *testing.T) {
+		client := newFakeClient()
+		for i := 0; i < 10; i++ {
+			client.err <- errors.New("caic is down")
+		}
+
+		cache := caic.NewClientCache(client)
+
+		_, err := cache.Summary(context.Background(), caic.EntireState)
+		require.EqualError(t, err, "caic is down")
+	})
+}
+
+func TestStatus(t *testing.T) {
+	client := newFakeClient()
+	client.regionResponse <- []caic.Zone{{Name: "Zone 1"}}
+	client.aspectDangerResponse <- caic.AspectDanger{}
+
+	cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Hour))
+	_, err := cache.Summary(context.Background(), caic.SawatchRange)
+	require.Nil(t, err)
+	_, err = cache.AspectDanger(context.Background(), caic.SawatchRange)
+	require.Nil(t, err)
+	_, err = cache.ForecastText(context.Background(), caic.Aspen)
+	require.Nil(t, err)
+
+	client.block = make(chan struct{})
+	defer close(client.block)
+	go cache.Summary(context.Background(), caic.FrontRange)
+	require.Eventually(t, func() bool {
+		return client.forecastCalls() == 3
+	}, time.Second, time.Millisecond)
+
+	status := cache.Status()
+	require.Len(t, status, 3)
+
+	require.Equal(t, "forecast", status[0].Kind)
+	require.Equal(t, caic.FrontRange, status[0].Region)
+	require.True(t, status[0].Refreshing)
+	require.True(t, status[0].Fetched.IsZero())
+
+	require.Equal(t, caic.SawatchRange, status[1].Region)
+	require.False(t, status[1].Refreshing)
+	require.False(t, status[1].Fetched.IsZero())
+	require.False(t, status[1].Stale)
+
+	require.Equal(t, caic.Aspen, status[2].Region)
+}
+
+func TestAspectDangerSummary(t *testing.T) {
+	t.Run("it caches responses for duration then serves them stale until refreshed", func(t *testing.T) {
+		client := newFakeClient()
+		client.aspectDangerResponse <- caic.AspectDanger{Region: caic.SteamboatFlatTops}
+
+		clock := newFakeClock()
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Minute), caic.WithClock(clock.Now))
+
+		call, err := cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
+		require.Nil(t, err)
+
+		cachedCall, err := cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
+		require.Nil(t, err)
 		require.Equal(t, call, cachedCall)
+
+		clock.Advance(2 * time.Minute)
+		release := client.hold()
+
+		staleCall, err := cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
+		var staleErr *caic.StaleError
+		require.True(t, errors.As(err, &staleErr))
+		require.Equal(t, call, staleCall)
+
+		client.aspectDangerResponse <- caic.AspectDanger{Region: caic.SawatchRange}
+		close(release)
+
+		var secondCall caic.AspectDanger
+		require.Eventually(t, func() bool {
+			secondCall, err = cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
+			return err == nil
+		}, time.Second, time.Millisecond)
+
 		require.Equal(t, caic.SteamboatFlatTops, call.Region)
 		require.Equal(t, caic.SawatchRange, secondCall.Region)
+		require.Equal(t, 2, client.forecastCalls())
 	})
 
 	//If incorrect, this test will fail when run with go test -race
@@ -115,16 +616,124 @@ func TestAspectDangerSummary(t *testing.T) {
 
 		cache := caic.NewClientCache(client, caic.WithCacheDuration(10*time.Millisecond))
 
-		_, err := cache.AspectDanger(caic.SteamboatFlatTops)
+		_, err := cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
 		require.NotNil(t, err)
 
-		secondCall, err := cache.AspectDanger(caic.SteamboatFlatTops)
+		secondCall, err := cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
 		require.Nil(t, err)
 
 		require.Equal(t, caic.Aspen, secondCall.Region)
 	})
 }
 
+func TestProblems(t *testing.T) {
+	t.Run("it caches responses for duration then serves them stale until refreshed", func(t *testing.T) {
+		client := newFakeClient()
+		client.problemsResponse <- []caic.AvalancheProblem{{Type: "Wind Slab"}}
+
+		clock := newFakeClock()
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Minute), caic.WithClock(clock.Now))
+
+		call, err := cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		cachedCall, err := cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, call, cachedCall)
+
+		clock.Advance(2 * time.Minute)
+		release := client.hold()
+
+		staleCall, err := cache.Problems(context.Background(), caic.FrontRange)
+		var staleErr *caic.StaleError
+		require.True(t, errors.As(err, &staleErr))
+		require.Equal(t, call, staleCall)
+
+		client.problemsResponse <- []caic.AvalancheProblem{{Type: "Wet Loose"}}
+		close(release)
+
+		var secondCall []caic.AvalancheProblem
+		require.Eventually(t, func() bool {
+			secondCall, err = cache.Problems(context.Background(), caic.FrontRange)
+			return err == nil
+		}, time.Second, time.Millisecond)
+
+		require.Equal(t, "Wind Slab", call[0].Type)
+		require.Equal(t, "Wet Loose", secondCall[0].Type)
+		require.Equal(t, 2, client.forecastCalls())
+	})
+
+	t.Run("it doesn't cache errors", func(t *testing.T) {
+		client := newFakeClient()
+		client.problemsResponse <- nil
+		client.problemsResponse <- []caic.AvalancheProblem{{Type: "Wet Loose"}}
+		client.err <- errors.New("something bad")
+
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(10*time.Millisecond))
+
+		_, err := cache.Problems(context.Background(), caic.FrontRange)
+		require.NotNil(t, err)
+
+		secondCall, err := cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, "Wet Loose", secondCall[0].Type)
+	})
+}
+
+func TestForecastText(t *testing.T) {
+	t.Run("it caches responses for duration then serves them stale until refreshed", func(t *testing.T) {
+		client := newFakeClient()
+		client.textResponse <- caic.ForecastText{BottomLine: "first"}
+
+		clock := newFakeClock()
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(time.Minute), caic.WithClock(clock.Now))
+
+		call, err := cache.ForecastText(context.Background(), caic.Gunnison)
+		require.Nil(t, err)
+
+		cachedCall, err := cache.ForecastText(context.Background(), caic.Gunnison)
+		require.Nil(t, err)
+		require.Equal(t, call, cachedCall)
+
+		clock.Advance(2 * time.Minute)
+		release := client.hold()
+
+		staleCall, err := cache.ForecastText(context.Background(), caic.Gunnison)
+		var staleErr *caic.StaleError
+		require.True(t, errors.As(err, &staleErr))
+		require.Equal(t, call, staleCall)
+
+		client.textResponse <- caic.ForecastText{BottomLine: "second"}
+		close(release)
+
+		var secondCall caic.ForecastText
+		require.Eventually(t, func() bool {
+			secondCall, err = cache.ForecastText(context.Background(), caic.Gunnison)
+			return err == nil
+		}, time.Second, time.Millisecond)
+
+		require.Equal(t, "first", call.BottomLine)
+		require.Equal(t, "second", secondCall.BottomLine)
+		require.Equal(t, 2, client.forecastCalls())
+	})
+
+	t.Run("it doesn't cache errors", func(t *testing.T) {
+		client := newFakeClient()
+		client.textResponse <- caic.ForecastText{}
+		client.textResponse <- caic.ForecastText{BottomLine: "second"}
+		client.err <- errors.New("something bad")
+
+		cache := caic.NewClientCache(client, caic.WithCacheDuration(10*time.Millisecond))
+
+		_, err := cache.ForecastText(context.Background(), caic.Gunnison)
+		require.NotNil(t, err)
+
+		secondCall, err := cache.ForecastText(context.Background(), caic.Gunnison)
+		require.Nil(t, err)
+		require.Equal(t, "second", secondCall.BottomLine)
+	})
+}
+
 func TestCanConnect(t *testing.T) {
 	t.Run("it does not cache responses", func(t *testing.T) {
 		client := newFakeClient()
@@ -132,8 +741,8 @@ func TestCanConnect(t *testing.T) {
 		client.canConnectResponse <- false
 
 		cache := caic.NewClientCache(client)
-		require.True(t, cache.CanConnect())
-		require.False(t, cache.CanConnect())
+		require.True(t, cache.CanConnect(context.Background()))
+		require.False(t, cache.CanConnect(context.Background()))
 	})
 }
 
@@ -144,7 +753,7 @@ func readRegions(start, stop chan struct{}, c *caic.Cache) {
 		case <-stop:
 			return
 		default:
-			c.Summary(caic.SteamboatFlatTops)
+			c.Summary(context.Background(), caic.SteamboatFlatTops)
 		}
 	}
 }
@@ -156,7 +765,7 @@ func readAspectDanger(start, stop chan struct{}, c *caic.Cache) {
 		case <-stop:
 			return
 		default:
-			c.AspectDanger(caic.SteamboatFlatTops)
+			c.AspectDanger(context.Background(), caic.SteamboatFlatTops)
 		}
 	}
 }
@@ -165,6 +774,8 @@ func newFakeClient() *fakeClient {
 	return &fakeClient{
 		regionResponse:       make(chan []caic.Zone, 10),
 		aspectDangerResponse: make(chan caic.AspectDanger, 10),
+		problemsResponse:     make(chan []caic.AvalancheProblem, 10),
+		textResponse:         make(chan caic.ForecastText, 10),
 		canConnectResponse:   make(chan bool, 10),
 		err:                  make(chan error, 10),
 	}
@@ -173,11 +784,17 @@ func newFakeClient() *fakeClient {
 type fakeClient struct {
 	regionResponse       chan []caic.Zone
 	aspectDangerResponse chan caic.AspectDanger
+	problemsResponse     chan []caic.AvalancheProblem
+	textResponse         chan caic.ForecastText
 	canConnectResponse   chan bool
+	block                chan struct{}
+	mu                   sync.Mutex
+	forecasts            int
+	lastCtx              context.Context
 	err                  chan error
 }
 
-func (c *fakeClient) CanConnect() bool {
+func (c *fakeClient) CanConnect(context.Context) bool {
 	select {
 	case ret := <-c.canConnectResponse:
 		return ret
@@ -186,22 +803,57 @@ func (c *fakeClient) CanConnect() bool {
 	}
 }
 
-func (c *fakeClient) Summary(caic.Region) ([]caic.Zone, error) {
+// Forecast returns whichever parts have a response waiting
+func (c *fakeClient) Forecast(ctx context.Context, r caic.Region) (caic.Forecast, error) {
+	c.mu.Lock()
+	c.forecasts++
+	c.lastCtx = ctx
+	block := c.block
+	c.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+
+	f := caic.Forecast{Region: r}
 	select {
-	case ret := <-c.regionResponse:
-		return ret, c.error()
+	case f.Zones = <-c.regionResponse:
+	default:
+	}
+	select {
+	case f.AspectDanger = <-c.aspectDangerResponse:
 	default:
-		return nil, c.error()
 	}
-}
-
-func (c *fakeClient) AspectDanger(caic.Region) (caic.AspectDanger, error) {
 	select {
-	case ret := <-c.aspectDangerResponse:
-		return ret, c.error()
+	case f.Problems = <-c.problemsResponse:
 	default:
-		return caic.AspectDanger{}, c.error()
 	}
+	select {
+	case f.Text = <-c.textResponse:
+	default:
+	}
+	return f, c.error()
+}
+
+// hold makes fetches wait until the returned channel is closed
+func (c *fakeClient) hold() chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.block = make(chan struct{})
+	return c.block
+}
+
+func (c *fakeClient) forecastCalls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.forecasts
+}
+
+func (c *fakeClient) forecastCtx() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastCtx
 }
 
 func (c *fakeClient) error() error {
@@ -212,3 +864,25 @@ func (c *fakeClient) error() error {
 		return nil
 	}
 }
+
+// fakeClock only moves when it's advanced
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now()}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -1,10 +1,14 @@
 package caic
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 const (
@@ -13,38 +17,131 @@ const (
 )
 
 type Client struct {
-	http    doer
-	caicURL string
+	http           Doer
+	caicURL        string
+	maxConcurrency int
+
+	m     sync.Mutex
+	pages map[string]validatedPage // by path
+
+	pagesInFlight chan struct{} // holds a token for each forecast being fetched
 }
 
-type doer interface {
-	Do(*http.Request) (*http.Response, error)
+// validatedPage is a parsed page with the validators CAIC sent with it,
+// kept so the page is only downloaded and parsed again when it changes
+type validatedPage struct {
+	doc          *goquery.Document
+	etag         string
+	lastModified string
 }
 
-func NewClient(caicURL string, http doer) *Client {
-	return &Client{
-		http:    http,
-		caicURL: caicURL,
+func NewClient(caicURL string, http Doer, opts ...ClientOption) *Client {
+	client := &Client{
+		http:           http,
+		caicURL:        caicURL,
+		maxConcurrency: defaultMaxConcurrency,
+		pages:          make(map[string]validatedPage),
 	}
+
+	for _, o := range opts {
+		o(client)
+	}
+	client.pagesInFlight = make(chan struct{}, client.maxConcurrency)
+
+	return client
 }
 
-func (c *Client) doRequest(path string) (string, error) {
-	req, err := http.NewRequest("GET", c.caicURL+path, nil)
+type ClientOption func(c *Client)
+
+// WithMaxConcurrency limits how many forecasts are fetched at once, like
+// when the cache fetches every region for the entire state
+func WithMaxConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxConcurrency = n
+		}
+	}
+}
+
+func (c *Client) doRequest(ctx context.Context, path string) (string, error) {
+	return get(ctx, c.http, c.caicURL+path)
+}
+
+// document returns the parsed page at path. When CAIC sent an ETag or
+// Last-Modified with the page last time, the request is conditional and the
+// page parsed last time is returned if it hasn't changed.
+func (c *Client) document(ctx context.Context, path string) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.caicURL+path, nil)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	c.m.Lock()
+	prev, validated := c.pages[path]
+	c.m.Unlock()
+
+	if validated {
+		if prev.etag != "" {
+			req.Header.Set("If-None-Match", prev.etag)
+		}
+		if prev.lastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.lastModified)
+		}
 	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && validated {
+		return prev.doc, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", errors.New(fmt.Sprint("unexpected status code ", resp.StatusCode))
+		return nil, errors.New(fmt.Sprint("unexpected status code ", resp.StatusCode))
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	v := validatedPage{
+		doc:          doc,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	c.m.Lock()
+	if v.etag != "" || v.lastModified != "" {
+		c.pages[path] = v
+	} else {
+		delete(c.pages, path)
+	}
+	c.m.Unlock()
+
+	return doc, nil
+}
+
+// get returns the body of url, failing unless it's a 200
+func get(ctx context.Context, d Doer, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := d.Do(req)
+	if err != nil {
+		return "", err
 	}
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(fmt.Sprint("unexpected status code ", resp.StatusCode))
+	}
+
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", err
@@ -0,0 +1,141 @@
+package caic_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+// pageServer serves a forecast page with the validators in header,
+// answering conditional requests that match them with a 304
+type pageServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	body        string
+	header      http.Header
+	statuses    []int
+	conditional []http.Header
+}
+
+func newPageServer(t testing.TB, body string, header http.Header) *pageServer {
+	s := &pageServer{body: body, header: header}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.conditional = append(s.conditional, http.Header{
+			"If-None-Match":     r.Header.Values("If-None-Match"),
+			"If-Modified-Since": r.Header.Values("If-Modified-Since"),
+		})
+
+		for k, v := range s.header {
+			w.Header()[k] = v
+		}
+
+		etag := s.header.Get("ETag")
+		lastModified := s.header.Get("Last-Modified")
+		if (etag != "" && r.Header.Get("If-None-Match") == etag) ||
+			(etag == "" && lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified) {
+			s.statuses = append(s.statuses, http.StatusNotModified)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		s.statuses = append(s.statuses, http.StatusOK)
+		_, _ = w.Write([]byte(s.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *pageServer) update(body string, header http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.body, s.header = body, header
+}
+
+func TestConditionalRequests(t *testing.T) {
+	t.Run("it reuses the parsed page when the ETag matches", func(t *testing.T) {
+		s := newPageServer(t, forecast, http.Header{"Etag": {`"v1"`}})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		first, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		second, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		require.Equal(t, first, second)
+		require.Equal(t, []int{http.StatusOK, http.StatusNotModified}, s.statuses)
+		require.Empty(t, s.conditional[0].Get("If-None-Match"))
+		require.Equal(t, `"v1"`, s.conditional[1].Get("If-None-Match"))
+	})
+
+	t.Run("it reuses the parsed page when it hasn't been modified", func(t *testing.T) {
+		lastModified := "Wed, 14 Apr 2021 16:30:00 GMT"
+		s := newPageServer(t, forecast, http.Header{"Last-Modified": {lastModified}})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		first, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		second, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		require.Equal(t, first, second)
+		require.Equal(t, []int{http.StatusOK, http.StatusNotModified}, s.statuses)
+		require.Equal(t, lastModified, s.conditional[1].Get("If-Modified-Since"))
+	})
+
+	t.Run("it parses the page again when it changes", func(t *testing.T) {
+		s := newPageServer(t, forecast, http.Header{"Etag": {`"v1"`}})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		first, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, 3, first.Zones[0].AboveTreeline)
+
+		s.update(strings.Replace(forecast, "Considerable (3)", "High (4)", 1), http.Header{"Etag": {`"v2"`}})
+		second, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, 4, second.Zones[0].AboveTreeline)
+
+		third, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, second, third)
+		require.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusNotModified}, s.statuses)
+		require.Equal(t, `"v2"`, s.conditional[2].Get("If-None-Match"))
+	})
+
+	t.Run("it keeps a page per region", func(t *testing.T) {
+		s := newPageServer(t, forecast, http.Header{"Etag": {`"v1"`}})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		_, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		_, err = client.Forecast(context.Background(), caic.Aspen)
+		require.Nil(t, err)
+
+		require.Equal(t, []int{http.StatusOK, http.StatusOK}, s.statuses)
+	})
+
+	t.Run("requests aren't conditional without validators", func(t *testing.T) {
+		s := newPageServer(t, forecast, http.Header{})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Forecast(context.Background(), caic.FrontRange)
+			require.Nil(t, err)
+		}
+
+		require.Equal(t, []int{http.StatusOK, http.StatusOK}, s.statuses)
+		require.Empty(t, s.conditional[1].Get("If-None-Match"))
+		require.Empty(t, s.conditional[1].Get("If-Modified-Since"))
+	})
+}
@@ -0,0 +1,30 @@
+package caic
+
+import "net/http"
+
+// Doer does HTTP requests, like an *http.Client. Clients fetch everything
+// with a Doer, which can be wrapped to change every request they make.
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// DefaultUserAgent identifies the plugin to CAIC, so they can tell who to
+// contact rather than blocking an anonymous scraper
+const DefaultUserAgent = "grafana-caic-datasource (+https://github.com/grafana/caic-datasource)"
+
+type userAgentDoer struct {
+	d         Doer
+	userAgent string
+}
+
+// NewUserAgentDoer returns a Doer that sends every request with the
+// User-Agent header set to userAgent
+func NewUserAgentDoer(d Doer, userAgent string) Doer {
+	return &userAgentDoer{d: d, userAgent: userAgent}
+}
+
+func (u *userAgentDoer) Do(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", u.userAgent)
+	return u.d.Do(req)
+}
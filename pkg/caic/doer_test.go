@@ -0,0 +1,29 @@
+package caic_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgentDoer(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+	}))
+	defer server.Close()
+
+	d := caic.NewUserAgentDoer(http.DefaultClient, "avalanche-dashboard/1.0")
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.Nil(t, err)
+
+	resp, err := d.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, "avalanche-dashboard/1.0", userAgent)
+	require.Empty(t, req.Header.Get("User-Agent"), "the caller's request is left alone")
+}
@@ -0,0 +1,139 @@
+package caic
+
+import (
+	"context"
+	"errors"
+)
+
+// The parts of a forecast. Each is read from the page on its own, so one
+// part missing from the page doesn't lose the others.
+const (
+	SummaryPart      = "summary"
+	AspectDangerPart = "aspectDanger"
+	ProblemsPart     = "problems"
+	TextPart         = "text"
+)
+
+// Forecast is everything read from a region's forecast page, which is
+// fetched and parsed once for all of its parts. The EntireState forecast
+// is the statewide page, which only has the aspect danger.
+type Forecast struct {
+	Region       Region
+	Zones        []Zone
+	AspectDanger AspectDanger
+	Problems     []AvalancheProblem
+	Text         ForecastText
+
+	// Errors are why parts couldn't be read from the page, by part
+	Errors map[string]*ParseError `json:",omitempty"`
+}
+
+// Source fetches whole forecasts for the cache, from the forecast pages
+// with a Client or the forecast products with a JSONClient
+type Source interface {
+	CanConnect(context.Context) bool
+	Forecast(context.Context, Region) (Forecast, error)
+}
+
+// Err returns why the part couldn't be read, or err when it could
+func (f Forecast) Err(part string, err error) error {
+	if parseErr, ok := f.Errors[part]; ok {
+		return parseErr
+	}
+	return err
+}
+
+// fail records why a part couldn't be read. Extractors only fail to find
+// elements on a page that's already been fetched, so every error is a
+// ParseError.
+func (f *Forecast) fail(part string, err error) {
+	if f.Errors == nil {
+		f.Errors = make(map[string]*ParseError)
+	}
+	f.Errors[part] = err.(*ParseError)
+}
+
+// Forecast fetches and parses the region's page once and reads every part
+// of the forecast from it
+func (c *Client) Forecast(ctx context.Context, r Region) (Forecast, error) {
+	select {
+	case c.pagesInFlight <- struct{}{}:
+		defer func() { <-c.pagesInFlight }()
+	case <-ctx.Done():
+		return Forecast{}, ctx.Err()
+	}
+
+	p, err := c.regionPage(ctx, r)
+	if err != nil {
+		return Forecast{}, err
+	}
+	return forecastFrom(p), nil
+}
+
+// forecastFrom runs every extractor on the page
+func forecastFrom(p page) Forecast {
+	f := Forecast{Region: p.region}
+
+	if p.region != EntireState {
+		z, err := zoneFrom(p)
+		if err != nil {
+			f.fail(SummaryPart, err)
+		} else {
+			f.Zones = []Zone{z}
+		}
+
+		if f.Problems, err = problemsFrom(p); err != nil {
+			f.fail(ProblemsPart, err)
+		}
+	}
+
+	f.AspectDanger = aspectDangerFrom(p)
+
+	f.Text = ForecastText{Region: p.region}
+	if p.region != EntireState {
+		f.Text = textFrom(p)
+	}
+	return f
+}
+
+// Forecast reads every part of the region's forecast from one fetch of the
+// products. Like the statewide page, the EntireState forecast only has the
+// aspect danger, from whichever regions have a forecast.
+func (c *JSONClient) Forecast(ctx context.Context, r Region) (Forecast, error) {
+	if r == EntireState {
+		products, err := c.products(ctx)
+		if err != nil {
+			return Forecast{}, err
+		}
+
+		var all []product
+		for _, r := range Regions() {
+			if p, ok := products[r]; ok {
+				all = append(all, p)
+			}
+		}
+		return Forecast{Region: r, AspectDanger: aspectDangerFor(r, all...), Text: ForecastText{Region: r}}, nil
+	}
+
+	p, err := c.product(ctx, r)
+
+	var parseErr *ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		f := Forecast{Region: r}
+		for _, part := range []string{SummaryPart, AspectDangerPart, ProblemsPart, TextPart} {
+			f.fail(part, parseErr)
+		}
+		return f, nil
+	case err != nil:
+		return Forecast{}, err
+	}
+
+	return Forecast{
+		Region:       r,
+		Zones:        []Zone{zoneFor(r, p)},
+		AspectDanger: aspectDangerFor(r, p),
+		Problems:     problemsFor(r, p),
+		Text:         forecastTextFor(r, p),
+	}, nil
+}
@@ -0,0 +1,230 @@
+package caic_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+// regionPage has every part of a forecast
+var regionPage = forecastWithTimes + avalancheProblems + forecastText
+
+func TestForecast(t *testing.T) {
+	t.Run("the cache serves every part from one fetch of the page", func(t *testing.T) {
+		s := newPageServer(t, regionPage, http.Header{})
+		cache := caic.NewClientCache(caic.NewClient(s.URL, http.DefaultClient))
+
+		zones, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, 3, zones[0].AboveTreeline)
+
+		ad, err := cache.AspectDanger(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.True(t, ad.AboveTreeline.North)
+
+		problems, err := cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Len(t, problems, 2)
+
+		text, err := cache.ForecastText(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, "Jane Forecaster", text.IssuedBy)
+
+		require.Len(t, s.statuses, 1)
+	})
+
+	t.Run("parts missing from the page don't lose the others", func(t *testing.T) {
+		s := newPageServer(t, avalancheProblems+forecastText, http.Header{})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		f, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Len(t, f.Problems, 2)
+		require.True(t, f.AspectDanger.AboveTreeline.North)
+		require.Equal(t, "Jane Forecaster", f.Text.IssuedBy)
+
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(f.Err(caic.SummaryPart, nil), &parseErr))
+		require.Empty(t, f.Zones)
+		require.Nil(t, f.Err(caic.AspectDangerPart, nil))
+		require.Nil(t, f.Err(caic.ProblemsPart, nil))
+	})
+
+	t.Run("pages without problems have no aspects in danger", func(t *testing.T) {
+		s := newPageServer(t, forecastWithTimes, http.Header{})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		f, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Empty(t, f.Errors)
+		require.Equal(t, 3, f.Zones[0].AboveTreeline)
+		require.Empty(t, f.AspectDanger.AboveTreeline.Aspects())
+	})
+
+	t.Run("the state page only has the aspect danger", func(t *testing.T) {
+		s := newPageServer(t, regionPage, http.Header{})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+
+		f, err := client.Forecast(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+		require.Empty(t, f.Zones)
+		require.Empty(t, f.Problems)
+		require.True(t, f.AspectDanger.AboveTreeline.North)
+		require.Equal(t, caic.ForecastText{Region: caic.EntireState}, f.Text)
+	})
+
+	t.Run("it returns the error when the page can't be fetched", func(t *testing.T) {
+		tc := setup(http.StatusBadGateway, nil)
+
+		_, err := tc.caicClient.Forecast(context.Background(), caic.FrontRange)
+		require.EqualError(t, err, "unexpected status code 502")
+	})
+
+	t.Run("it fetches at most max concurrency pages at once", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil, caic.WithMaxConcurrency(2))
+		tc.fakeHttp.delay = 5 * time.Millisecond
+
+		var wg sync.WaitGroup
+		for _, r := range caic.Regions() {
+			wg.Add(1)
+			go func(r caic.Region) {
+				defer wg.Done()
+				tc.caicClient.Forecast(context.Background(), r)
+			}(r)
+		}
+		wg.Wait()
+
+		require.Equal(t, 2, tc.fakeHttp.maxInFlight)
+	})
+}
+
+func TestJSONForecast(t *testing.T) {
+	t.Run("it reads every part from the products", func(t *testing.T) {
+		client, _ := productServer(t, "testdata/products.json")
+
+		f, err := client.Forecast(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Empty(t, f.Errors)
+		require.Equal(t, caic.FrontRange, f.Zones[0].Index)
+		require.Equal(t, []string{"N", "NE", "E"}, f.AspectDanger.AboveTreeline.Aspects())
+		require.Len(t, f.Problems, 2)
+		require.Equal(t, "Jane Forecaster", f.Text.IssuedBy)
+	})
+
+	t.Run("concurrent forecasts share one request for the products", func(t *testing.T) {
+		body, err := os.ReadFile("testdata/products.json")
+		require.Nil(t, err)
+
+		var requests int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			<-release
+			w.Write(body)
+		}))
+		t.Cleanup(server.Close)
+		client := caic.NewJSONClient(server.URL, server.Client())
+
+		var wg sync.WaitGroup
+		forecasts := make([]caic.Forecast, len(caic.Regions()))
+		for i, r := range caic.Regions() {
+			wg.Add(1)
+			go func(i int, r caic.Region) {
+				defer wg.Done()
+				forecasts[i], _ = client.Forecast(context.Background(), r)
+			}(i, r)
+		}
+
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&requests) == 1
+		}, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+		require.Equal(t, caic.FrontRange, forecasts[caic.FrontRange].Region)
+		require.NotEmpty(t, forecasts[caic.FrontRange].Zones)
+	})
+
+	t.Run("regions without a product can't be read", func(t *testing.T) {
+		client, _ := productServer(t, "testdata/products.json")
+
+		f, err := client.Forecast(context.Background(), caic.GrandMesa)
+		require.Nil(t, err)
+
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(f.Err(caic.TextPart, nil), &parseErr))
+	})
+
+	t.Run("the entire state has the aspect danger of the regions with a product", func(t *testing.T) {
+		client, _ := productServer(t, "testdata/products.json")
+
+		f, err := client.Forecast(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+		require.Empty(t, f.Errors)
+		require.Equal(t, caic.EntireState, f.AspectDanger.Region)
+		require.Equal(t, []string{"N", "NE", "E"}, f.AspectDanger.AboveTreeline.Aspects())
+		require.Equal(t, caic.ForecastText{Region: caic.EntireState}, f.Text)
+	})
+}
+
+// BenchmarkRegionPage compares reading every part of a forecast a part at
+// a time, which fetched and parsed the page for each before the parts
+// shared a page, with reading it from one Forecast
+func BenchmarkRegionPage(b *testing.B) {
+	b.Run("per part", func(b *testing.B) {
+		s := newPageServer(b, regionPage, http.Header{})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+		ctx := context.Background()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, part := range []string{caic.SummaryPart, caic.AspectDangerPart, caic.ProblemsPart, caic.TextPart} {
+				f, _ := client.Forecast(ctx, caic.FrontRange)
+				_ = f.Err(part, nil)
+			}
+		}
+		b.ReportMetric(float64(len(s.statuses))/float64(b.N), "requests/op")
+	})
+
+	b.Run("shared page", func(b *testing.B) {
+		s := newPageServer(b, regionPage, http.Header{})
+		client := caic.NewClient(s.URL, http.DefaultClient)
+		ctx := context.Background()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			client.Forecast(ctx, caic.FrontRange)
+		}
+		b.ReportMetric(float64(len(s.statuses))/float64(b.N), "requests/op")
+	})
+}
+
+// BenchmarkCacheRefresh fills an empty cache with every part of the
+// entire state, like a dashboard with a panel for each. Every region page
+// and the statewide page are fetched once.
+func BenchmarkCacheRefresh(b *testing.B) {
+	s := newPageServer(b, regionPage, http.Header{})
+	client := caic.NewClient(s.URL, http.DefaultClient)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache := caic.NewClientCache(client)
+		cache.Summary(ctx, caic.EntireState)
+		cache.AspectDanger(ctx, caic.EntireState)
+		cache.Problems(ctx, caic.EntireState)
+		cache.ForecastText(ctx, caic.EntireState)
+	}
+	b.ReportMetric(float64(len(s.statuses))/float64(b.N), "requests/op")
+}
@@ -0,0 +1,382 @@
+package caic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ProductsPath is where the JSON forecast products are served from, and the
+// Page of a ParseError from reading them
+const ProductsPath = "/products/all"
+
+// The default limit on the shared fetch of the products, which no caller's
+// deadline applies to
+const defaultFetchTimeout = 30 * time.Second
+
+// JSONClient reads forecasts from CAIC's JSON forecast products instead of
+// scraping the forecast pages. Every region's forecast comes from a single
+// request for all of the products.
+type JSONClient struct {
+	http         Doer
+	apiURL       string
+	fetchTimeout time.Duration
+
+	m        sync.Mutex
+	inFlight *productsCall // the fetch of the products callers are sharing
+}
+
+// productsCall is a fetch of the products shared by concurrent callers
+type productsCall struct {
+	done     chan struct{}
+	products map[Region]product
+	err      error
+}
+
+func NewJSONClient(apiURL string, http Doer, opts ...JSONClientOption) *JSONClient {
+	client := &JSONClient{
+		http:         http,
+		apiURL:       apiURL,
+		fetchTimeout: defaultFetchTimeout,
+	}
+
+	for _, o := range opts {
+		o(client)
+	}
+
+	return client
+}
+
+type JSONClientOption func(c *JSONClient)
+
+// WithFetchTimeout limits how long the shared fetch of the products can
+// take
+func WithFetchTimeout(d time.Duration) JSONClientOption {
+	return func(c *JSONClient) {
+		if d > 0 {
+			c.fetchTimeout = d
+		}
+	}
+}
+
+// product is a zone forecast in CAIC's product format. Only the parts the
+// client uses are decoded.
+type product struct {
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Forecaster     string    `json:"forecaster"`
+	IssueDateTime  time.Time `json:"issueDateTime"`
+	ExpiryDateTime time.Time `json:"expiryDateTime"`
+
+	DangerRatings struct {
+		Days []struct {
+			Position int    `json:"position"`
+			Alp      string `json:"alp"`
+			Tln      string `json:"tln"`
+			Btl      string `json:"btl"`
+		} `json:"days"`
+	} `json:"dangerRatings"`
+
+	AvalancheProblems struct {
+		Days [][]productProblem `json:"days"`
+	} `json:"avalancheProblems"`
+
+	AvalancheSummary       productText `json:"avalancheSummary"`
+	TerrainAndTravelAdvice productText `json:"terrainAndTravelAdvice"`
+	ForecastDiscussion     productText `json:"forecastDiscussion"`
+	WeatherSummary         productText `json:"weatherSummary"`
+}
+
+type productProblem struct {
+	Type             string   `json:"type"`
+	AspectElevations []string `json:"aspectElevations"` // like "ne_alp"
+	Likelihood       string   `json:"likelihood"`
+	ExpectedSize     struct {
+		Min string `json:"min"`
+		Max string `json:"max"`
+	} `json:"expectedSize"`
+}
+
+// productText is HTML written for each day of the forecast
+type productText struct {
+	Days []struct {
+		Content string `json:"content"`
+	} `json:"days"`
+}
+
+const forecastProduct = "avalancheforecast"
+
+var dangerLevels = map[string]int{
+	"low":          1,
+	"moderate":     2,
+	"considerable": 3,
+	"high":         4,
+	"extreme":      5,
+}
+
+// Avalanche sizes on the relative scale, as the forecast pages name them
+var sizes = map[string]string{
+	"1": "Small",
+	"2": "Large",
+	"3": "Very Large",
+	"4": "Historic",
+}
+
+// The elevations of aspectElevations, indexed like elevations
+var productElevations = []string{"btl", "tln", "alp"}
+
+func (c *JSONClient) CanConnect(ctx context.Context) bool {
+	_, err := get(ctx, c.http, c.apiURL+ProductsPath)
+	return err == nil
+}
+
+// products returns the forecast for each region. Concurrent callers share
+// one request, so refreshing every region at once fetches the products
+// once.
+func (c *JSONClient) products(ctx context.Context) (map[Region]product, error) {
+	c.m.Lock()
+	cl := c.inFlight
+	if cl == nil {
+		cl = &productsCall{done: make(chan struct{})}
+		c.inFlight = cl
+
+		// The fetch outlives the caller that started it for the others
+		go func() {
+			ctx, cancel := context.WithTimeout(detached{ctx}, c.fetchTimeout)
+			defer cancel()
+			cl.products, cl.err = c.fetchProducts(ctx)
+
+			c.m.Lock()
+			c.inFlight = nil
+			c.m.Unlock()
+			close(cl.done)
+		}()
+	}
+	c.m.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.products, cl.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchProducts requests the forecast for each region, matched on its
+// title. The testdata fixture is written by hand, not recorded from CAIC, so
+// neither the product fields nor titles being the region names are checked
+// against a real response yet.
+func (c *JSONClient) fetchProducts(ctx context.Context) (map[Region]product, error) {
+	body, err := get(ctx, c.http, c.apiURL+ProductsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []product
+	if err := json.Unmarshal([]byte(body), &all); err != nil {
+		return nil, fmt.Errorf("unable to read CAIC forecast products: %w", err)
+	}
+
+	titles := make(map[string]Region)
+	for _, r := range Regions() {
+		titles[strings.ToLower(r.String())] = r
+	}
+
+	products := make(map[Region]product)
+	for _, p := range all {
+		r, ok := titles[strings.ToLower(strings.TrimSpace(p.Title))]
+		if p.Type == forecastProduct && ok {
+			products[r] = p
+		}
+	}
+	return products, nil
+}
+
+// product returns the forecast for a single region
+func (c *JSONClient) product(ctx context.Context, r Region) (product, error) {
+	products, err := c.products(ctx)
+	if err != nil {
+		return product{}, err
+	}
+
+	p, ok := products[r]
+	if !ok {
+		return product{}, c.parseError(r)
+	}
+	return p, nil
+}
+
+func (c *JSONClient) parseError(r Region) *ParseError {
+	return &ParseError{Selector: r.String(), Region: r, Page: ProductsPath}
+}
+
+func zoneFor(r Region, p product) Zone {
+	z := Zone{
+		Index:   r,
+		Name:    r.String(),
+		Issued:  p.IssueDateTime.In(denver),
+		Expires: p.ExpiryDateTime.In(denver),
+	}
+
+	for _, d := range p.DangerRatings.Days {
+		above, near, below := dangerLevels[d.Alp], dangerLevels[d.Tln], dangerLevels[d.Btl]
+		switch d.Position {
+		case 1:
+			z.AboveTreeline, z.NearTreeline, z.BelowTreeline = above, near, below
+		case 2:
+			z.TomorrowAboveTreeline, z.TomorrowNearTreeline, z.TomorrowBelowTreeline = above, near, below
+		}
+	}
+
+	z.Rating = max(z.AboveTreeline, z.NearTreeline, z.BelowTreeline)
+	z.TomorrowRating = max(z.TomorrowAboveTreeline, z.TomorrowNearTreeline, z.TomorrowBelowTreeline)
+	return z
+}
+
+// aspectDangerFor returns every aspect and elevation with a problem today
+// in any of the forecasts. It was issued with the latest of them and
+// expires with the first.
+func aspectDangerFor(r Region, products ...product) AspectDanger {
+	var aspects []string
+	var issued, expires time.Time
+	for _, p := range products {
+		for _, problem := range todaysProblems(p) {
+			aspects = append(aspects, problem.AspectElevations...)
+		}
+		if p.IssueDateTime.After(issued) {
+			issued = p.IssueDateTime
+		}
+		if expires.IsZero() || p.ExpiryDateTime.Before(expires) {
+			expires = p.ExpiryDateTime
+		}
+	}
+
+	rose := roseFrom(aspects)
+	return AspectDanger{
+		Region:        r,
+		BelowTreeline: rose[0],
+		NearTreeline:  rose[1],
+		AboveTreeline: rose[2],
+		Issued:        issued.In(denver),
+		Expires:       expires.In(denver),
+	}
+}
+
+func problemsFor(r Region, p product) []AvalancheProblem {
+	var problems []AvalancheProblem
+	for _, problem := range todaysProblems(p) {
+		rose := roseFrom(problem.AspectElevations)
+		problems = append(problems, AvalancheProblem{
+			Region:        r,
+			Type:          words(problem.Type),
+			Likelihood:    words(problem.Likelihood),
+			MinSize:       sizeName(problem.ExpectedSize.Min),
+			MaxSize:       sizeName(problem.ExpectedSize.Max),
+			BelowTreeline: rose[0],
+			NearTreeline:  rose[1],
+			AboveTreeline: rose[2],
+			Issued:        p.IssueDateTime.In(denver),
+			Expires:       p.ExpiryDateTime.In(denver),
+		})
+	}
+	return problems
+}
+
+func forecastTextFor(r Region, p product) ForecastText {
+	return ForecastText{
+		Region:         r,
+		IssuedBy:       p.Forecaster,
+		BottomLine:     p.AvalancheSummary.today(),
+		TravelAdvice:   p.TerrainAndTravelAdvice.today(),
+		Discussion:     p.ForecastDiscussion.today(),
+		WeatherSummary: p.WeatherSummary.today(),
+		Issued:         p.IssueDateTime.In(denver),
+		Expires:        p.ExpiryDateTime.In(denver),
+	}
+}
+
+// todaysProblems returns the problems for the first day of the forecast
+func todaysProblems(p product) []productProblem {
+	if len(p.AvalancheProblems.Days) == 0 {
+		return nil
+	}
+	return p.AvalancheProblems.Days[0]
+}
+
+// today returns the text for the first day of the forecast without its markup
+func (t productText) today() string {
+	if len(t.Days) == 0 {
+		return ""
+	}
+
+	doc, err := toDocument(t.Days[0].Content)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// roseFrom reads aspect elevations like "ne_alp" into a rose indexed like
+// elevations. Unknown ones are ignored.
+func roseFrom(aspectElevations []string) []OrdinalDanger {
+	on := make([][]bool, len(productElevations))
+	for i := range on {
+		on[i] = make([]bool, len(ordinals))
+	}
+
+	for _, ae := range aspectElevations {
+		parts := strings.SplitN(strings.ToLower(ae), "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		for e, pe := range productElevations {
+			for o, ordinal := range ordinals {
+				if parts[0] == strings.ToLower(ordinal) && parts[1] == pe {
+					on[e][o] = true
+				}
+			}
+		}
+	}
+
+	rose := make([]OrdinalDanger, len(productElevations))
+	for e := range rose {
+		rose[e] = OrdinalDanger{
+			North:     on[e][0],
+			NorthEast: on[e][1],
+			East:      on[e][2],
+			SouthEast: on[e][3],
+			South:     on[e][4],
+			SouthWest: on[e][5],
+			West:      on[e][6],
+			NorthWest: on[e][7],
+		}
+	}
+	return rose
+}
+
+// words turns camel case like "persistentSlab" into "Persistent Slab"
+func words(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case i == 0:
+			r = unicode.ToUpper(r)
+		case unicode.IsUpper(r):
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sizeName(s string) string {
+	if name, ok := sizes[s]; ok {
+		return name
+	}
+	return s
+}
@@ -0,0 +1,194 @@
+package caic_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+// productServer serves the fixture as CAIC's forecast products
+func productServer(t *testing.T, fixture string) (*caic.JSONClient, *httptest.Server) {
+	t.Helper()
+
+	body, err := os.ReadFile(fixture)
+	require.Nil(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/products/all" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return caic.NewJSONClient(server.URL, server.Client()), server
+}
+
+// productCache serves each part of the fixture's forecasts, like the
+// datasource does
+func productCache(t *testing.T, fixture string) *caic.Cache {
+	t.Helper()
+
+	client, _ := productServer(t, fixture)
+	return caic.NewClientCache(client)
+}
+
+func TestJSONSummary(t *testing.T) {
+	issued := time.Date(2021, 4, 14, 22, 30, 0, 0, time.UTC)
+
+	t.Run("it reads a region's ratings", func(t *testing.T) {
+		cache := productCache(t, "testdata/products.json")
+
+		zones, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Len(t, zones, 1)
+
+		z := zones[0]
+		require.Equal(t, caic.FrontRange, z.Index)
+		require.Equal(t, "Front Range", z.Name)
+		require.Equal(t, []int{3, 3, 2, 1}, []int{z.Rating, z.AboveTreeline, z.NearTreeline, z.BelowTreeline})
+		require.Equal(t, []int{4, 4, 3, 2}, []int{z.TomorrowRating, z.TomorrowAboveTreeline, z.TomorrowNearTreeline, z.TomorrowBelowTreeline})
+		require.True(t, issued.Equal(z.Issued))
+		require.Equal(t, "America/Denver", z.Issued.Location().String())
+		require.True(t, issued.AddDate(0, 0, 1).Equal(z.Expires))
+	})
+
+	t.Run("it returns the regions with a forecast for the entire state", func(t *testing.T) {
+		cache := productCache(t, "testdata/products.json")
+
+		zones, err := cache.Summary(context.Background(), caic.EntireState)
+		require.Len(t, zones, 2)
+		require.Equal(t, "Front Range", zones[0].Name)
+		require.Equal(t, "Aspen", zones[1].Name)
+
+		var partialErr *caic.PartialError
+		require.True(t, errors.As(err, &partialErr))
+		require.Len(t, partialErr.Errors, 8)
+	})
+
+	t.Run("it fails for a region without a forecast", func(t *testing.T) {
+		cache := productCache(t, "testdata/products.json")
+
+		_, err := cache.Summary(context.Background(), caic.GrandMesa)
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(err, &parseErr))
+		require.Equal(t, caic.GrandMesa, parseErr.Region)
+	})
+
+	t.Run("it fails when the products can't be read", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<html>not json</html>`))
+		}))
+		defer server.Close()
+
+		cache := caic.NewClientCache(caic.NewJSONClient(server.URL, server.Client()))
+		_, err := cache.Summary(context.Background(), caic.FrontRange)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "unable to read CAIC forecast products")
+	})
+}
+
+func TestJSONProblems(t *testing.T) {
+	cache := productCache(t, "testdata/products.json")
+
+	t.Run("it reads today's problems", func(t *testing.T) {
+		problems, err := cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Len(t, problems, 2)
+
+		p := problems[0]
+		require.Equal(t, caic.FrontRange, p.Region)
+		require.Equal(t, "Wind Slab", p.Type)
+		require.Equal(t, "Likely", p.Likelihood)
+		require.Equal(t, "Small", p.MinSize)
+		require.Equal(t, "Large", p.MaxSize)
+		require.Equal(t, []string{"N", "NE", "E"}, p.AboveTreeline.Aspects())
+		require.Equal(t, []string{"N", "NE"}, p.NearTreeline.Aspects())
+		require.Empty(t, p.BelowTreeline.Aspects())
+
+		require.Equal(t, "Persistent Slab", problems[1].Type)
+		require.Equal(t, []string{"N", "NW"}, problems[1].BelowTreeline.Aspects())
+	})
+
+	t.Run("a forecast can have no problems", func(t *testing.T) {
+		problems, err := cache.Problems(context.Background(), caic.Aspen)
+		require.Nil(t, err)
+		require.Empty(t, problems)
+	})
+
+	t.Run("the aspect danger is every aspect with a problem", func(t *testing.T) {
+		ad, err := cache.AspectDanger(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Equal(t, []string{"N", "NE", "E"}, ad.AboveTreeline.Aspects())
+		require.Equal(t, []string{"N", "NE"}, ad.NearTreeline.Aspects())
+		require.Equal(t, []string{"N", "NW"}, ad.BelowTreeline.Aspects())
+	})
+
+	t.Run("the aspect danger for the entire state is every region's", func(t *testing.T) {
+		ad, err := cache.AspectDanger(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+		require.Equal(t, caic.EntireState, ad.Region)
+		require.Equal(t, []string{"N", "NE", "E"}, ad.AboveTreeline.Aspects())
+		require.Equal(t, []string{"N", "NW"}, ad.BelowTreeline.Aspects())
+	})
+}
+
+func TestJSONForecastText(t *testing.T) {
+	cache := productCache(t, "testdata/products.json")
+
+	text, err := cache.ForecastText(context.Background(), caic.FrontRange)
+	require.Nil(t, err)
+	require.Equal(t, caic.ForecastText{
+		Region:         caic.FrontRange,
+		IssuedBy:       "Jane Forecaster",
+		BottomLine:     "Wind slabs are likely near ridgelines.",
+		TravelAdvice:   "Avoid wind loaded slopes.",
+		Discussion:     "Strong winds moved new snow.",
+		WeatherSummary: "Clear and cold.",
+		Issued:         text.Issued,
+		Expires:        text.Expires,
+	}, text)
+
+	text, err = cache.ForecastText(context.Background(), caic.Aspen)
+	require.Nil(t, err)
+	require.Equal(t, "", text.WeatherSummary)
+	require.Equal(t, "", text.TravelAdvice)
+}
+
+func TestJSONEntireStateForecastText(t *testing.T) {
+	cache := productCache(t, "testdata/products.json")
+
+	text, err := cache.ForecastText(context.Background(), caic.EntireState)
+	require.Nil(t, err)
+	require.Equal(t, caic.ForecastText{Region: caic.EntireState}, text)
+}
+
+func TestJSONProductsTimeout(t *testing.T) {
+	deadlines := make(chan bool, 1)
+	client := caic.NewJSONClient("http://caic.test", doerFunc(func(req *http.Request) (*http.Response, error) {
+		_, ok := req.Context().Deadline()
+		deadlines <- ok
+		return nil, errors.New("unreachable")
+	}))
+
+	_, err := client.Forecast(context.Background(), caic.FrontRange)
+	require.NotNil(t, err)
+	require.True(t, <-deadlines, "the shared fetch of the products has no deadline")
+}
+
+func TestJSONCanConnect(t *testing.T) {
+	client, server := productServer(t, "testdata/products.json")
+	require.True(t, client.CanConnect(context.Background()))
+
+	server.Close()
+	require.False(t, client.CanConnect(context.Background()))
+}
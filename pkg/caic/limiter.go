@@ -0,0 +1,109 @@
+package caic
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// How fast every client in the process can request pages from CAIC. The
+// burst covers a single region's forecast, the rate spreads out the pages
+// of the entire state.
+const (
+	sharedRate  = 2 // requests per second
+	sharedBurst = 10
+)
+
+var sharedLimiter = NewRateLimiter(sharedRate, sharedBurst)
+
+// SharedRateLimiter returns the limiter shared by every datasource in the
+// process, so adding datasources doesn't add load on CAIC
+func SharedRateLimiter() *RateLimiter {
+	return sharedLimiter
+}
+
+// RateLimiter is a token bucket. Tokens are added at a steady rate up to
+// the burst, and every request takes one, waiting for it if the bucket is
+// empty.
+type RateLimiter struct {
+	m      sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64 // negative when callers are waiting for tokens
+	last   time.Time
+}
+
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token, waiting until there is one or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, even if it hasn't been added yet, and returns
+// how long until it is
+func (l *RateLimiter) reserve() time.Duration {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel gives back a reserved token that wasn't used
+func (l *RateLimiter) cancel() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.tokens++
+}
+
+type rateLimitedDoer struct {
+	d Doer
+	l *RateLimiter
+}
+
+// NewRateLimitedDoer returns a Doer that waits for a token from l before
+// each request
+func NewRateLimitedDoer(d Doer, l *RateLimiter) Doer {
+	return &rateLimitedDoer{d: d, l: l}
+}
+
+func (r *rateLimitedDoer) Do(req *http.Request) (*http.Response, error) {
+	if err := r.l.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return r.d.Do(req)
+}
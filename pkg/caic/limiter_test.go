@@ -0,0 +1,75 @@
+package caic_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("it allows a burst then waits for tokens", func(t *testing.T) {
+		l := caic.NewRateLimiter(20, 2)
+
+		start := time.Now()
+		require.Nil(t, l.Wait(context.Background()))
+		require.Nil(t, l.Wait(context.Background()))
+		require.Less(t, int64(time.Since(start)), int64(20*time.Millisecond))
+
+		require.Nil(t, l.Wait(context.Background()))
+		require.Nil(t, l.Wait(context.Background()))
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(90*time.Millisecond))
+	})
+
+	t.Run("it gives the token back when the wait is cancelled", func(t *testing.T) {
+		l := caic.NewRateLimiter(10, 1)
+		require.Nil(t, l.Wait(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Equal(t, context.Canceled, l.Wait(ctx))
+
+		// Only the first token is still owed, not the cancelled one
+		start := time.Now()
+		require.Nil(t, l.Wait(context.Background()))
+		require.Less(t, int64(time.Since(start)), int64(150*time.Millisecond))
+	})
+
+	t.Run("it's shared by every limited doer", func(t *testing.T) {
+		var requests int32
+		d := doerFunc(func(*http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requests, 1)
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})
+
+		l := caic.NewRateLimiter(1, 2)
+		first := caic.NewRateLimitedDoer(d, l)
+		second := caic.NewRateLimitedDoer(d, l)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := doRequest(t, ctx, first, http.MethodGet, "http://caic.test")
+		require.Nil(t, err)
+		_, err = doRequest(t, ctx, second, http.MethodGet, "http://caic.test")
+		require.Nil(t, err)
+		_, err = doRequest(t, ctx, first, http.MethodGet, "http://caic.test")
+		require.Equal(t, context.DeadlineExceeded, err)
+		require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("every client in the process shares a limiter", func(t *testing.T) {
+		require.NotNil(t, caic.SharedRateLimiter())
+		require.Same(t, caic.SharedRateLimiter(), caic.SharedRateLimiter())
+	})
+}
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
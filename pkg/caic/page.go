@@ -0,0 +1,52 @@
+package caic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ParseError is returned when a forecast page is missing an element the
+// client depends on, usually because CAIC changed its markup or there is
+// no forecast for the season
+type ParseError struct {
+	Selector string
+	Region   Region
+	Page     string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("unable to parse %s forecast: %q not found on %s", e.Region, e.Selector, e.Page)
+}
+
+// page is a parsed forecast page and where it came from
+type page struct {
+	*goquery.Document
+	region Region
+	path   string
+}
+
+func (c *Client) regionPage(ctx context.Context, r Region) (page, error) {
+	path := fmt.Sprintf(regionPath, r)
+	doc, err := c.document(ctx, path)
+	if err != nil {
+		return page{}, err
+	}
+
+	return page{Document: doc, region: r, path: path}, nil
+}
+
+// find returns the elements matching the selector or a ParseError when
+// there aren't any
+func (p page) find(selector string) (*goquery.Selection, error) {
+	s := p.Find(selector)
+	if s.Length() == 0 {
+		return nil, p.parseError(selector)
+	}
+	return s, nil
+}
+
+func (p page) parseError(selector string) *ParseError {
+	return &ParseError{Selector: selector, Region: p.region, Page: p.path}
+}
@@ -1,12 +1,20 @@
 package caic
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
 
 type AspectDanger struct {
 	Region        Region
 	BelowTreeline OrdinalDanger
 	NearTreeline  OrdinalDanger
 	AboveTreeline OrdinalDanger
+	Issued        time.Time
+	Expires       time.Time
 }
 
 type OrdinalDanger struct {
@@ -20,64 +28,165 @@ type OrdinalDanger struct {
 	NorthWest bool
 }
 
+// Aspects returns the ordinals that are a danger, in compass order
+func (od OrdinalDanger) Aspects() []string {
+	var aspects []string
+	for i, on := range []bool{od.North, od.NorthEast, od.East, od.SouthEast, od.South, od.SouthWest, od.West, od.NorthWest} {
+		if on {
+			aspects = append(aspects, ordinals[i])
+		}
+	}
+	return aspects
+}
+
+// AvalancheProblem is a single problem from a zone forecast along with
+// the aspects and elevations where it is found
+type AvalancheProblem struct {
+	Region        Region
+	Type          string
+	Likelihood    string
+	MinSize       string
+	MaxSize       string
+	BelowTreeline OrdinalDanger
+	NearTreeline  OrdinalDanger
+	AboveTreeline OrdinalDanger
+	Issued        time.Time
+	Expires       time.Time
+}
+
 var (
 	ordinals   = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
 	elevations = []string{"Btl", "Tln", "Alp"}
 )
 
-func (c *Client) AspectDanger(r Region) (AspectDanger, error) {
-	resp, err := c.doRequest(fmt.Sprintf(regionPath, r))
+// aspectDangerFrom reads the first problem's rose from the page. Pages
+// without a rose have no aspects in danger.
+func aspectDangerFrom(p page) AspectDanger {
+	rose := roseFor(p, 0)
+	issued, expires := forecastTimes(p.Document)
+	return AspectDanger{
+		Region:        p.region,
+		BelowTreeline: rose[0],
+		NearTreeline:  rose[1],
+		AboveTreeline: rose[2],
+		Issued:        issued,
+		Expires:       expires,
+	}
+}
+
+// problemsFrom reads every problem listed on the page
+func problemsFrom(p page) ([]AvalancheProblem, error) {
+	issued, expires := forecastTimes(p.Document)
+
+	var problems []AvalancheProblem
+	for i := range p.Find("div.ProblemRose").Nodes {
+		problem, err := problemFor(p, i)
+		if err != nil {
+			return nil, err
+		}
+
+		problem.Region = p.region
+		problem.Issued = issued
+		problem.Expires = expires
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
+
+func problemFor(p page, i int) (AvalancheProblem, error) {
+	problemType, err := requiredTextFor(p, fmt.Sprintf("#ProblemType_%d", i))
 	if err != nil {
-		return AspectDanger{}, err
+		return AvalancheProblem{}, err
 	}
 
-	doc, err := toDocument(resp)
+	likelihood, err := requiredTextFor(p, fmt.Sprintf("#Likelihood_%d", i))
 	if err != nil {
-		return AspectDanger{}, err
+		return AvalancheProblem{}, err
 	}
 
-	aspects := make(map[int]map[int]bool)
-	for ei, e := range elevations {
-		od := make(map[int]bool)
-		for oi, o := range ordinals {
-			id := fmt.Sprintf("#%s%s_0.on", o, e)
-			element := doc.Find(id)
-			od[oi] = element.Nodes != nil // The element is on or it doesn't exit
-		}
-		aspects[ei] = od
+	size, err := requiredTextFor(p, fmt.Sprintf("#Size_%d", i))
+	if err != nil {
+		return AvalancheProblem{}, err
 	}
 
-	return AspectDanger{
-		Region: r,
-		BelowTreeline: OrdinalDanger{
-			North:     aspects[0][0],
-			NorthEast: aspects[0][1],
-			East:      aspects[0][2],
-			SouthEast: aspects[0][3],
-			South:     aspects[0][4],
-			SouthWest: aspects[0][5],
-			West:      aspects[0][6],
-			NorthWest: aspects[0][7],
-		},
-		NearTreeline: OrdinalDanger{
-			North:     aspects[1][0],
-			NorthEast: aspects[1][1],
-			East:      aspects[1][2],
-			SouthEast: aspects[1][3],
-			South:     aspects[1][4],
-			SouthWest: aspects[1][5],
-			West:      aspects[1][6],
-			NorthWest: aspects[1][7],
-		},
-		AboveTreeline: OrdinalDanger{
-			North:     aspects[2][0],
-			NorthEast: aspects[2][1],
-			East:      aspects[2][2],
-			SouthEast: aspects[2][3],
-			South:     aspects[2][4],
-			SouthWest: aspects[2][5],
-			West:      aspects[2][6],
-			NorthWest: aspects[2][7],
-		},
+	rose := roseFor(p, i)
+	minSize, maxSize := parseSize(size)
+	return AvalancheProblem{
+		Type:          problemType,
+		Likelihood:    likelihood,
+		MinSize:       minSize,
+		MaxSize:       maxSize,
+		BelowTreeline: rose[0],
+		NearTreeline:  rose[1],
+		AboveTreeline: rose[2],
 	}, nil
 }
+
+// roseFor reads the rose of the given problem, indexed like elevations
+func roseFor(p page, problem int) []OrdinalDanger {
+	rose := make([]OrdinalDanger, len(elevations))
+	for i := range elevations {
+		rose[i] = ordinalDangerFor(p, i, problem)
+	}
+	return rose
+}
+
+// ordinalDangerFor reads which ordinals are lit on the rose of the given problem
+// at the given index into elevations. Ordinals missing from the page, like
+// on a day without any problems, aren't a danger.
+func ordinalDangerFor(p page, elevation, problem int) OrdinalDanger {
+	on := make([]bool, len(ordinals))
+	for i, o := range ordinals {
+		id := fmt.Sprintf("#%s%s_%d", o, elevations[elevation], problem)
+		on[i] = p.Find(id).HasClass("on")
+	}
+
+	return OrdinalDanger{
+		North:     on[0],
+		NorthEast: on[1],
+		East:      on[2],
+		SouthEast: on[3],
+		South:     on[4],
+		SouthWest: on[5],
+		West:      on[6],
+		NorthWest: on[7],
+	}
+}
+
+func requiredTextFor(p page, query string) (string, error) {
+	s, err := p.find(query)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s.First().Text()), nil
+}
+
+// textFor returns the text of the first element matching the query, with
+// a blank line between its paragraphs
+func textFor(doc *goquery.Document, query string) string {
+	s := doc.Find(query).First()
+
+	paragraphs := s.Find("p")
+	if paragraphs.Length() == 0 {
+		return strings.TrimSpace(s.Text())
+	}
+
+	var texts []string
+	paragraphs.Each(func(_ int, p *goquery.Selection) {
+		if t := strings.TrimSpace(p.Text()); t != "" {
+			texts = append(texts, t)
+		}
+	})
+	return strings.Join(texts, "\n\n")
+}
+
+// parseSize splits a size range like "Small to Large" into its bounds. A
+// single size is both the min and the max.
+func parseSize(s string) (string, string) {
+	parts := strings.SplitN(s, " to ", 2)
+	if len(parts) == 1 {
+		size := strings.TrimSpace(parts[0])
+		return size, size
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
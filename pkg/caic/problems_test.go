@@ -1,8 +1,13 @@
 package caic_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/caic-datasource/pkg/caic"
 	"github.com/stretchr/testify/require"
@@ -13,7 +18,7 @@ func TestGetRegionAspectInfo(t *testing.T) {
 		tc := setup(http.StatusOK, nil)
 		tc.fakeHttp.resp <- avalancheProblem
 
-		aspectDanger, _ := tc.caicClient.AspectDanger(caic.SteamboatFlatTops)
+		aspectDanger, _ := tc.cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
 		require.Equal(t, baseURL+"/caic/pub_bc_avo.php?zone_id=0", tc.fakeHttp.reqs[0].URL.String())
 		require.Equal(t, http.MethodGet, tc.fakeHttp.reqs[0].Method)
 
@@ -37,15 +42,159 @@ func TestGetRegionAspectInfo(t *testing.T) {
 		)
 	})
 
+	t.Run("it returns when the forecast was issued and when it expires", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecastWithTimes + avalancheProblem
+
+		aspectDanger, err := tc.cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
+		require.Nil(t, err)
+
+		require.Equal(t, "2021-04-14T16:30:00-06:00", aspectDanger.Issued.Format(time.RFC3339))
+		require.Equal(t, "2021-04-15T16:30:00-06:00", aspectDanger.Expires.Format(time.RFC3339))
+	})
+
+	t.Run("it returns an error when the request fails", func(t *testing.T) {
+		tc := setup(http.StatusNotFound, nil)
+
+		_, err := tc.cache.AspectDanger(context.Background(), caic.SteamboatFlatTops)
+		require.NotNil(t, err)
+	})
+}
+
+func TestGetRegionProblems(t *testing.T) {
+	t.Run("it returns each avalanche problem with its own aspects and elevations", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- avalancheProblems
+
+		problems, err := tc.cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+
+		require.Equal(t, baseURL+"/caic/pub_bc_avo.php?zone_id=1", tc.fakeHttp.reqs[0].URL.String())
+		require.Equal(
+			t,
+			[]caic.AvalancheProblem{
+				{
+					Region:     caic.FrontRange,
+					Type:       "Persistent Slab",
+					Likelihood: "Likely",
+					MinSize:    "Small",
+					MaxSize:    "Large",
+					NearTreeline: caic.OrdinalDanger{
+						North:     true,
+						NorthEast: true,
+					},
+					AboveTreeline: caic.OrdinalDanger{
+						North:     true,
+						NorthEast: true,
+					},
+				},
+				{
+					Region:     caic.FrontRange,
+					Type:       "Wind Slab",
+					Likelihood: "Possible",
+					MinSize:    "Small",
+					MaxSize:    "Small",
+					AboveTreeline: caic.OrdinalDanger{
+						East:      true,
+						SouthEast: true,
+					},
+				},
+			},
+			problems,
+		)
+	})
+
+	t.Run("it returns no problems when none are listed", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecast
+
+		problems, err := tc.cache.Problems(context.Background(), caic.FrontRange)
+		require.Nil(t, err)
+		require.Empty(t, problems)
+	})
+
+	t.Run("it returns the problems for every region when region is EntireState", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		for i := 0; i < 10; i++ {
+			tc.fakeHttp.resp <- avalancheProblems
+		}
+
+		problems, err := tc.cache.Problems(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+
+		require.Len(t, tc.fakeHttp.reqs, 10)
+		require.Len(t, problems, 20)
+		require.Equal(t, caic.SteamboatFlatTops, problems[0].Region)
+		require.Equal(t, caic.SangreDeCristo, problems[19].Region)
+	})
+
 	t.Run("it returns an error when the request fails", func(t *testing.T) {
 		tc := setup(http.StatusNotFound, nil)
 
-		_, err := tc.caicClient.AspectDanger(caic.SteamboatFlatTops)
+		_, err := tc.cache.Problems(context.Background(), caic.FrontRange)
 		require.NotNil(t, err)
 	})
 }
 
+func TestProblemParseErrors(t *testing.T) {
+	t.Run("AspectDanger has no aspects in danger when the rose is missing", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecast
+
+		ad, err := tc.cache.AspectDanger(context.Background(), caic.Gunnison)
+		require.Nil(t, err)
+		require.Equal(t, caic.AspectDanger{Region: caic.Gunnison}, ad)
+	})
+
+	t.Run("Problems returns a ParseError when part of a problem is missing", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- `<h4 id="ProblemType_0">Wind Slab</h4><span id="Size_0">Small</span>` + rose(0)
+
+		_, err := tc.cache.Problems(context.Background(), caic.Gunnison)
+
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(err, &parseErr))
+		require.Equal(t, "#Likelihood_0", parseErr.Selector)
+		require.Contains(t, err.Error(), "Gunnison")
+	})
+
+	t.Run("Problems returns a ParseError when a listed problem has no details", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- avalancheProblem + `
+			<h4 id="ProblemType_0">Wind Slab</h4>
+			<span id="Likelihood_0">Likely</span>
+			<span id="Size_0">Small</span>
+			<div class="ProblemRose"><div id="NBtl_1" class="NBtl on"></div></div>`
+
+		_, err := tc.cache.Problems(context.Background(), caic.Gunnison)
+
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(err, &parseErr))
+		require.Equal(t, "#ProblemType_1", parseErr.Selector)
+	})
+}
+
+func TestOrdinalDangerAspects(t *testing.T) {
+	od := caic.OrdinalDanger{North: true, South: true, NorthWest: true}
+	require.Equal(t, []string{"N", "S", "NW"}, od.Aspects())
+	require.Empty(t, caic.OrdinalDanger{}.Aspects())
+}
+
 var (
+	avalancheProblems = `
+	<div class="avalanche-problem">
+		<h4 id="ProblemType_0">Persistent Slab</h4>
+		<span id="Likelihood_0">Likely</span>
+		<span id="Size_0">Small to Large</span>
+		` + rose(0, "NTln", "NAlp", "NETln", "NEAlp") + `
+	</div>
+	<div class="avalanche-problem">
+		<h4 id="ProblemType_1">Wind Slab</h4>
+		<span id="Likelihood_1">Possible</span>
+		<span id="Size_1">Small</span>
+		` + rose(1, "EAlp", "SEAlp") + `
+	</div>`
+
 	avalancheProblem = `
 	<div class="ProblemRose">
 		<div id="NBtl_0"  class="NBtl  off"></div>
@@ -77,3 +226,26 @@ var (
 		<div id="SWBtl_0" class="SWBtl off"></div>
 	</div>`
 )
+
+// rose builds the aspect and elevation rose of a problem with the given
+// elements, like NAlp, turned on
+func rose(problem int, on ...string) string {
+	lit := make(map[string]bool)
+	for _, o := range on {
+		lit[o] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<div class="ProblemRose">`)
+	for _, e := range []string{"Btl", "Tln", "Alp"} {
+		for _, o := range []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"} {
+			state := "off"
+			if lit[o+e] {
+				state = "on"
+			}
+			sb.WriteString(fmt.Sprintf(`<div id="%s%s_%d" class="%s%s %s"></div>`, o, e, problem, o, e, state))
+		}
+	}
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
@@ -1,5 +1,7 @@
 package caic
 
+import "fmt"
+
 type Region int
 
 const (
@@ -16,21 +18,77 @@ const (
 	SangreDeCristo
 )
 
+// The number of regions in the state
+const regionCount = int(SangreDeCristo) + 1
+
+var regionNames = []string{
+	"Steamboat & Flat Tops",
+	"Front Range",
+	"Vail & Summit County",
+	"Sawatch Range",
+	"Aspen",
+	"Gunnison",
+	"Grand Mesa",
+	"Northern San Juan",
+	"Southern San Juan",
+	"Sangre de Cristo",
+}
+
 func (d Region) String() string {
 	if d == EntireState {
 		return "Entire State"
 	}
+	if d < 0 || int(d) >= len(regionNames) {
+		return fmt.Sprintf("Region(%d)", int(d))
+	}
+	return regionNames[d]
+}
+
+// Regions returns every region in the state, in order
+func Regions() []Region {
+	regions := make([]Region, regionCount)
+	for i := range regions {
+		regions[i] = Region(i)
+	}
+	return regions
+}
+
+// Bounds is an approximate bounding box, in degrees
+type Bounds struct {
+	North float64 `json:"north"`
+	South float64 `json:"south"`
+	East  float64 `json:"east"`
+	West  float64 `json:"west"`
+}
+
+// regionBounds are rough boxes around each region, estimated by hand
+// rather than taken from CAIC's published zone boundaries
+var regionBounds = []Bounds{
+	{North: 41.0, South: 39.8, East: -106.5, West: -107.6},
+	{North: 41.0, South: 39.3, East: -105.3, West: -106.3},
+	{North: 39.8, South: 39.3, East: -105.8, West: -106.6},
+	{North: 39.4, South: 38.3, East: -105.9, West: -106.7},
+	{North: 39.4, South: 38.9, East: -106.5, West: -107.3},
+	{North: 39.0, South: 38.3, East: -106.6, West: -107.6},
+	{North: 39.3, South: 38.8, East: -107.6, West: -108.4},
+	{North: 38.2, South: 37.6, East: -107.3, West: -108.2},
+	{North: 37.8, South: 37.0, East: -106.2, West: -107.4},
+	{North: 38.4, South: 37.0, East: -105.0, West: -105.8},
+}
+
+// Bounds returns the approximate extent of the region, good enough to
+// place it on a map but not to tell which region a point is in. Regions
+// that don't exist get the bounds of the state.
+func (d Region) Bounds() Bounds {
+	if d < 0 || int(d) >= len(regionBounds) {
+		return Bounds{North: 41.0, South: 37.0, East: -102.05, West: -109.05}
+	}
+	return regionBounds[d]
+}
 
-	return []string{
-		"Steamboat & Flat Tops",
-		"Front Range",
-		"Vail & Summit County",
-		"Sawatch Range",
-		"Aspen",
-		"Gunnison",
-		"Grand Mesa",
-		"Northern San Juan",
-		"Southern San Juan",
-		"Sangre de Cristo",
-	}[d]
+// Centroid returns the latitude and longitude of the middle of the
+// region's bounds, a point to mark the region with on a map
+func (d Region) Centroid() (float64, float64) {
+	b := d.Bounds()
+	return (b.North + b.South) / 2, (b.East + b.West) / 2
 }
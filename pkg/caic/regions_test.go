@@ -0,0 +1,33 @@
+package caic_test
+
+import (
+	"testing"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionBounds(t *testing.T) {
+	t.Run("every region is placed in Colorado", func(t *testing.T) {
+		state := caic.EntireState.Bounds()
+		for _, r := range caic.Regions() {
+			b := r.Bounds()
+			require.True(t, b.North <= state.North && b.South >= state.South, r.String())
+			require.True(t, b.East <= state.East && b.West >= state.West, r.String())
+
+			lat, lon := r.Centroid()
+			require.True(t, lat > b.South && lat < b.North, "%s centroid latitude %f", r, lat)
+			require.True(t, lon > b.West && lon < b.East, "%s centroid longitude %f", r, lon)
+		}
+	})
+
+	t.Run("the entire state is centered on Colorado", func(t *testing.T) {
+		lat, lon := caic.EntireState.Centroid()
+		require.InDelta(t, 39.0, lat, 0.1)
+		require.InDelta(t, -105.55, lon, 0.1)
+	})
+
+	t.Run("unknown regions are placed on the state", func(t *testing.T) {
+		require.Equal(t, caic.EntireState.Bounds(), caic.Region(42).Bounds())
+	})
+}
@@ -0,0 +1,300 @@
+package caic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// The statuses CAIC's server returns when it's briefly overloaded
+var transientStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// Methods that can be repeated without changing anything twice
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+type retryDoer struct {
+	d              Doer
+	attempts       int
+	attemptTimeout time.Duration
+	baseDelay      time.Duration
+	maxDelay       time.Duration
+	limiter        *RateLimiter
+}
+
+// NewRetryDoer returns a Doer that retries idempotent requests that fail
+// with a network error, time out or get a transient status like a 503.
+// Retries back off exponentially with jitter, or wait as long as the
+// server's Retry-After asks. The last response or error is returned when
+// every attempt fails.
+func NewRetryDoer(d Doer, opts ...RetryOption) Doer {
+	r := &retryDoer{
+		d:              d,
+		attempts:       3,
+		attemptTimeout: 10 * time.Second,
+		baseDelay:      500 * time.Millisecond,
+		maxDelay:       10 * time.Second,
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+type RetryOption func(r *retryDoer)
+
+// RetryDuration returns the longest a request through a Doer made by
+// NewRetryDoer with the options can take, with every attempt timing out
+// and the longest wait before each retry. Waits for the rate limiter
+// aren't included. It's zero when attempts have no timeout.
+func RetryDuration(opts ...RetryOption) time.Duration {
+	r := NewRetryDoer(nil, opts...).(*retryDoer)
+	if r.attemptTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(r.attempts)*r.attemptTimeout + time.Duration(r.attempts-1)*r.maxDelay
+}
+
+// WithAttempts sets how many times a request is tried, including the
+// first time
+func WithAttempts(n int) RetryOption {
+	return func(r *retryDoer) {
+		if n > 0 {
+			r.attempts = n
+		}
+	}
+}
+
+// WithAttemptTimeout limits how long each attempt can take, including
+// reading the response body
+func WithAttemptTimeout(d time.Duration) RetryOption {
+	return func(r *retryDoer) {
+		r.attemptTimeout = d
+	}
+}
+
+// WithRateLimiter makes every attempt wait for a token from l. The wait
+// is on the request's context, before the attempt timeout starts, so a
+// busy limiter doesn't time attempts out.
+func WithRateLimiter(l *RateLimiter) RetryOption {
+	return func(r *retryDoer) {
+		r.limiter = l
+	}
+}
+
+// WithBackoff sets the delay before the first retry, which doubles for
+// each retry after it up to max. A Retry-After longer than max isn't
+// waited for.
+func WithBackoff(base, max time.Duration) RetryOption {
+	return func(r *retryDoer) {
+		r.baseDelay = base
+		r.maxDelay = max
+	}
+}
+
+func (r *retryDoer) Do(req *http.Request) (*http.Response, error) {
+	if !r.retryable(req) {
+		return r.attempt(req)
+	}
+
+	for i := 1; ; i++ {
+		resp, err := r.attempt(req)
+		if i == r.attempts || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		delay, retry := r.retryAfter(i, resp, err)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			discard(resp)
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryable is whether the request can be sent again
+func (r *retryDoer) retryable(req *http.Request) bool {
+	if !idempotentMethods[req.Method] {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// attempt does the request once within the attempt timeout, once it has a
+// token from the limiter. The timeout covers reading the body, so it's
+// only released when the body is closed.
+func (r *retryDoer) attempt(req *http.Request) (*http.Response, error) {
+	if r.limiter != nil {
+		if err := r.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.attemptTimeout <= 0 {
+		return r.d.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), r.attemptTimeout)
+	resp, err := r.d.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryAfter returns how long to wait before retrying after the given
+// attempt and whether it should be retried at all
+func (r *retryDoer) retryAfter(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		return r.backoff(attempt), transient(err)
+	}
+	if !transientStatuses[resp.StatusCode] {
+		return 0, false
+	}
+
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		return wait, wait <= r.maxDelay
+	}
+	return r.backoff(attempt), true
+}
+
+// backoff returns the delay after the given attempt: the base delay
+// doubled for each earlier retry, capped at the max delay, with the upper
+// half jittered so clients that failed together don't retry together
+func (r *retryDoer) backoff(attempt int) time.Duration {
+	d := r.baseDelay
+	for i := 1; i < attempt && d < r.maxDelay; i++ {
+		d *= 2
+	}
+	if d > r.maxDelay {
+		d = r.maxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// transient is whether a request that failed with err might work if it's
+// tried again
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		// The attempt timed out. The request's own deadline is checked
+		// before retrying.
+		return true
+	}
+
+	// Every error from an *http.Client is a *url.Error, it's what it wraps
+	// that says whether it's worth retrying
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date
+func parseRetryAfter(s string, now time.Time) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(s); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	t, err := http.ParseTime(s)
+	if err != nil {
+		return 0, false
+	}
+	if wait := t.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// discard drains and closes a response that won't be used so its
+// connection can be reused
+func discard(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cancelBody releases an attempt's timeout once its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
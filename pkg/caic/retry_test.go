@@ -0,0 +1,229 @@
+package caic_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first failures requests with fail and answers the
+// rest with "ok". It returns the server and how many requests it got.
+func flakyServer(t *testing.T, failures int32, fail http.HandlerFunc) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			fail(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func status(code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(code)
+	}
+}
+
+func doRequest(t *testing.T, ctx context.Context, d caic.Doer, method, url string) (*http.Response, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	require.Nil(t, err)
+	return d.Do(req)
+}
+
+func requireBody(t *testing.T, resp *http.Response, body string) {
+	t.Helper()
+
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, body, string(b))
+}
+
+func fastRetries(opts ...caic.RetryOption) caic.Doer {
+	opts = append([]caic.RetryOption{caic.WithBackoff(time.Millisecond, 10*time.Millisecond)}, opts...)
+	return caic.NewRetryDoer(http.DefaultClient, opts...)
+}
+
+func TestRetryDuration(t *testing.T) {
+	t.Run("it leaves time for every attempt and the longest waits between them", func(t *testing.T) {
+		require.Equal(t, 50*time.Second, caic.RetryDuration())
+		require.Equal(t, 3*time.Minute+20*time.Second, caic.RetryDuration(caic.WithAttemptTimeout(time.Minute)))
+		require.Equal(t, 5*time.Second, caic.RetryDuration(caic.WithAttempts(2), caic.WithAttemptTimeout(2*time.Second), caic.WithBackoff(time.Second, time.Second)))
+	})
+
+	t.Run("it's unlimited when attempts are", func(t *testing.T) {
+		require.Zero(t, caic.RetryDuration(caic.WithAttemptTimeout(0)))
+	})
+}
+
+func TestRetryDoer(t *testing.T) {
+	t.Run("it retries transient statuses", func(t *testing.T) {
+		for _, code := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+			server, requests := flakyServer(t, 2, status(code))
+
+			resp, err := doRequest(t, context.Background(), fastRetries(), http.MethodGet, server.URL)
+			require.Nil(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			requireBody(t, resp, "ok")
+			require.Equal(t, int32(3), atomic.LoadInt32(requests))
+		}
+	})
+
+	t.Run("it returns the last response when every attempt fails", func(t *testing.T) {
+		server, requests := flakyServer(t, 10, status(http.StatusServiceUnavailable))
+
+		resp, err := doRequest(t, context.Background(), fastRetries(caic.WithAttempts(4)), http.MethodGet, server.URL)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, int32(4), atomic.LoadInt32(requests))
+	})
+
+	t.Run("it doesn't retry other statuses", func(t *testing.T) {
+		for _, code := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+			server, requests := flakyServer(t, 1, status(code))
+
+			resp, err := doRequest(t, context.Background(), fastRetries(), http.MethodGet, server.URL)
+			require.Nil(t, err)
+			resp.Body.Close()
+			require.Equal(t, code, resp.StatusCode)
+			require.Equal(t, int32(1), atomic.LoadInt32(requests))
+		}
+	})
+
+	t.Run("it doesn't retry requests that aren't idempotent", func(t *testing.T) {
+		server, requests := flakyServer(t, 1, status(http.StatusServiceUnavailable))
+
+		resp, err := doRequest(t, context.Background(), fastRetries(), http.MethodPost, server.URL)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, int32(1), atomic.LoadInt32(requests))
+	})
+
+	t.Run("it waits as long as Retry-After asks", func(t *testing.T) {
+		server, requests := flakyServer(t, 1, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		start := time.Now()
+		resp, err := doRequest(t, context.Background(), fastRetries(caic.WithBackoff(time.Millisecond, 5*time.Second)), http.MethodGet, server.URL)
+		require.Nil(t, err)
+		requireBody(t, resp, "ok")
+		require.Equal(t, int32(2), atomic.LoadInt32(requests))
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(time.Second))
+	})
+
+	t.Run("it gives up when Retry-After is longer than the max delay", func(t *testing.T) {
+		server, requests := flakyServer(t, 1, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		resp, err := doRequest(t, context.Background(), fastRetries(), http.MethodGet, server.URL)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Equal(t, int32(1), atomic.LoadInt32(requests))
+	})
+
+	t.Run("it retries attempts that time out", func(t *testing.T) {
+		server, requests := flakyServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		})
+
+		resp, err := doRequest(t, context.Background(), fastRetries(caic.WithAttemptTimeout(50*time.Millisecond)), http.MethodGet, server.URL)
+		require.Nil(t, err)
+		requireBody(t, resp, "ok")
+		require.Equal(t, int32(2), atomic.LoadInt32(requests))
+	})
+
+	t.Run("it retries dropped connections", func(t *testing.T) {
+		server, requests := flakyServer(t, 1, func(w http.ResponseWriter, _ *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.Nil(t, err)
+			conn.Close()
+		})
+
+		resp, err := doRequest(t, context.Background(), fastRetries(), http.MethodGet, server.URL)
+		require.Nil(t, err)
+		requireBody(t, resp, "ok")
+		require.Equal(t, int32(2), atomic.LoadInt32(requests))
+	})
+
+	t.Run("it doesn't retry errors that won't go away", func(t *testing.T) {
+		d := caic.NewRetryDoer(http.DefaultClient, caic.WithBackoff(time.Hour, time.Hour))
+
+		_, err := doRequest(t, context.Background(), d, http.MethodGet, "gopher://localhost")
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "unsupported protocol scheme")
+	})
+
+	t.Run("it stops when the request is cancelled", func(t *testing.T) {
+		server, requests := flakyServer(t, 10, status(http.StatusServiceUnavailable))
+		d := caic.NewRetryDoer(http.DefaultClient, caic.WithBackoff(time.Hour, time.Hour))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := doRequest(t, ctx, d, http.MethodGet, server.URL)
+		require.Equal(t, context.DeadlineExceeded, err)
+		require.Less(t, int64(time.Since(start)), int64(time.Second))
+		require.Equal(t, int32(1), atomic.LoadInt32(requests))
+	})
+
+	t.Run("every attempt waits for a token from the limiter", func(t *testing.T) {
+		server, requests := flakyServer(t, 10, status(http.StatusServiceUnavailable))
+		l := caic.NewRateLimiter(1, 3)
+
+		resp, err := doRequest(t, context.Background(), fastRetries(caic.WithRateLimiter(l)), http.MethodGet, server.URL)
+		require.Nil(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, int32(3), atomic.LoadInt32(requests))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		require.Equal(t, context.DeadlineExceeded, l.Wait(ctx))
+	})
+
+	t.Run("waiting for a token doesn't count against the attempt timeout", func(t *testing.T) {
+		server, requests := flakyServer(t, 0, nil)
+		d := fastRetries(
+			caic.WithAttempts(1),
+			caic.WithAttemptTimeout(20*time.Millisecond),
+			caic.WithRateLimiter(caic.NewRateLimiter(10, 1)),
+		)
+
+		for i := 0; i < 3; i++ {
+			resp, err := doRequest(t, context.Background(), d, http.MethodGet, server.URL)
+			require.Nil(t, err)
+			requireBody(t, resp, "ok")
+		}
+		require.Equal(t, int32(3), atomic.LoadInt32(requests))
+	})
+
+	t.Run("a client fetches through it", func(t *testing.T) {
+		server, requests := flakyServer(t, 1, status(http.StatusBadGateway))
+
+		client := caic.NewClient(server.URL, fastRetries())
+		require.True(t, client.CanConnect(context.Background()))
+		require.Equal(t, int32(2), atomic.LoadInt32(requests))
+	})
+}
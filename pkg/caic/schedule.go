@@ -0,0 +1,105 @@
+package caic
+
+import "time"
+
+// Schedule is when CAIC publishes forecasts. The cache uses it to keep a
+// forecast until it's replaced instead of for a fixed duration.
+type Schedule struct {
+	// Publish is the time of day, in America/Denver, new forecasts are published
+	Publish time.Duration
+
+	// Window is how late after Publish a new forecast can still show up
+	Window time.Duration
+
+	// RetryInterval is how often to check for a new forecast that is due
+	// but hasn't been published yet
+	RetryInterval time.Duration
+}
+
+// DefaultSchedule matches CAIC's afternoon issuance
+var DefaultSchedule = Schedule{
+	Publish:       16*time.Hour + 30*time.Minute,
+	Window:        2 * time.Hour,
+	RetryInterval: 5 * time.Minute,
+}
+
+// Expires returns when a forecast issued and expiring at the given times,
+// fetched at now, should be fetched again. That's when it expires or the
+// next one is published, whichever is first. While a new forecast is due
+// but not out yet, it's checked for every RetryInterval. Zero times are
+// unknown.
+func (s Schedule) Expires(issued, expires, now time.Time) time.Time {
+	next := s.nextPublish(now)
+	prev := next.AddDate(0, 0, -1)
+	retry := now.Add(s.RetryInterval)
+
+	var e time.Time
+	switch {
+	case now.Sub(prev) < s.Window && issued.Before(prev):
+		// The forecast for today is due
+		e = retry
+	case !expires.IsZero() && !expires.After(now) && now.Sub(expires) < s.Window:
+		// The forecast has expired and its replacement is late
+		e = retry
+	case expires.After(now) && expires.Before(next):
+		e = expires
+	default:
+		e = next
+	}
+
+	if e.Before(retry) {
+		return retry
+	}
+	return e
+}
+
+// nextPublish returns the first scheduled publish after now
+func (s Schedule) nextPublish(now time.Time) time.Time {
+	y, m, d := now.In(denver).Date()
+
+	// Set the wall clock rather than adding to midnight so DST changes
+	// don't move the publish time
+	h, min := int(s.Publish/time.Hour), int(s.Publish%time.Hour/time.Minute)
+	next := time.Date(y, m, d, h, min, 0, 0, denver)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// forecastTimesOf returns the earliest issue and expiry times of the
+// forecasts in a cached value
+func forecastTimesOf(v interface{}) (time.Time, time.Time) {
+	var issued, expires []time.Time
+	switch v := v.(type) {
+	case []Zone:
+		for _, z := range v {
+			issued, expires = append(issued, z.Issued), append(expires, z.Expires)
+		}
+	case AspectDanger:
+		issued, expires = []time.Time{v.Issued}, []time.Time{v.Expires}
+	case []AvalancheProblem:
+		for _, p := range v {
+			issued, expires = append(issued, p.Issued), append(expires, p.Expires)
+		}
+	case ForecastText:
+		issued, expires = []time.Time{v.Issued}, []time.Time{v.Expires}
+	case Forecast:
+		for _, part := range []interface{}{v.Zones, v.AspectDanger, v.Problems, v.Text} {
+			i, e := forecastTimesOf(part)
+			issued, expires = append(issued, i), append(expires, e)
+		}
+	}
+	return earliest(issued), earliest(expires)
+}
+
+// earliest returns the earliest time that isn't zero, or zero if they all are
+func earliest(times []time.Time) time.Time {
+	var e time.Time
+	for _, t := range times {
+		if !t.IsZero() && (e.IsZero() || t.Before(e)) {
+			e = t
+		}
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+package caic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleExpires(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	require.Nil(t, err)
+
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2021, month, day, hour, min, 0, 0, denver)
+	}
+
+	tests := []struct {
+		name     string
+		issued   time.Time
+		expires  time.Time
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "a current forecast lasts until it expires",
+			issued:   at(4, 14, 16, 30),
+			expires:  at(4, 15, 16, 30),
+			now:      at(4, 15, 9, 0),
+			expected: at(4, 15, 16, 30),
+		},
+		{
+			name:     "a forecast that outlives the schedule lasts until the next publish",
+			issued:   at(4, 14, 16, 30),
+			expires:  at(4, 16, 16, 30),
+			now:      at(4, 15, 9, 0),
+			expected: at(4, 15, 16, 30),
+		},
+		{
+			name:     "a late forecast is retried",
+			issued:   at(4, 14, 16, 30),
+			expires:  at(4, 15, 16, 30),
+			now:      at(4, 15, 16, 45),
+			expected: at(4, 15, 16, 50),
+		},
+		{
+			name:     "a forecast due today is retried even if it hasn't expired",
+			issued:   at(4, 14, 16, 30),
+			expires:  at(4, 15, 23, 0),
+			now:      at(4, 15, 17, 0),
+			expected: at(4, 15, 17, 5),
+		},
+		{
+			name:     "a new forecast lasts until it expires",
+			issued:   at(4, 15, 16, 40),
+			expires:  at(4, 16, 16, 30),
+			now:      at(4, 15, 16, 45),
+			expected: at(4, 16, 16, 30),
+		},
+		{
+			name:     "a forecast that's very late is checked at the next publish",
+			issued:   at(4, 14, 16, 30),
+			expires:  at(4, 15, 16, 30),
+			now:      at(4, 15, 20, 0),
+			expected: at(4, 16, 16, 30),
+		},
+		{
+			name:     "a forecast without times lasts until the next publish",
+			now:      at(4, 15, 9, 0),
+			expected: at(4, 15, 16, 30),
+		},
+		{
+			name:     "a forecast expiring soon is kept for at least the retry interval",
+			issued:   at(4, 14, 16, 30),
+			expires:  at(4, 15, 9, 1),
+			now:      at(4, 15, 9, 0),
+			expected: at(4, 15, 9, 5),
+		},
+		{
+			name:     "the publish time is in Denver across DST changes",
+			issued:   at(3, 13, 16, 30),
+			expires:  at(3, 14, 16, 30),
+			now:      at(3, 14, 9, 0),
+			expected: at(3, 14, 16, 30),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expires := caic.DefaultSchedule.Expires(tt.issued, tt.expires, tt.now)
+			require.True(t, tt.expected.Equal(expires), "expected %s, got %s", tt.expected, expires)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package caic
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultMaxConcurrency = 4
+
+// RegionError is the failure to fetch a single region of the state
+type RegionError struct {
+	Region Region
+	Err    error
+}
+
+func (e RegionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Region, e.Err)
+}
+
+func (e RegionError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is returned along with the regions that were fetched when
+// some regions of the state failed
+type PartialError struct {
+	Errors []RegionError
+	Total  int // how many regions were fetched, every region in the state when zero
+}
+
+func (e *PartialError) Error() string {
+	var msgs []string
+	for _, re := range e.Errors {
+		msgs = append(msgs, re.Error())
+	}
+	total := e.Total
+	if total == 0 {
+		total = regionCount
+	}
+	return fmt.Sprintf("unable to fetch %d of %d regions: %s", len(e.Errors), total, strings.Join(msgs, "; "))
+}
@@ -0,0 +1,141 @@
+package caic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// storeVersion is bumped whenever the stored entries or the types they hold
+// change, so files written by older versions are ignored instead of misread
+const storeVersion = 2
+
+// store keeps cache entries on disk, one JSON file per key
+type store struct {
+	dir string
+}
+
+type storedEntry struct {
+	Version int             `json:"version"`
+	Kind    string          `json:"kind"`
+	Region  Region          `json:"region"`
+	Fetched time.Time       `json:"fetched"`
+	Expires time.Time       `json:"expires"`
+	Value   json.RawMessage `json:"value"`
+}
+
+func (s *store) path(key cacheKey) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%d.json", key.kind, key.region))
+}
+
+// save writes the entry to a temporary file and renames it into place so a
+// crash never leaves a half written entry behind
+func (s *store) save(key cacheKey, e entry) error {
+	v, err := json.Marshal(e.value)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(storedEntry{
+		Version: storeVersion,
+		Kind:    key.kind,
+		Region:  key.region,
+		Fetched: e.fetched,
+		Expires: e.expires,
+		Value:   v,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+// load reads every entry in the store. Files that are corrupt or were
+// written by another version are skipped and returned as errors alongside
+// the entries that could be read.
+func (s *store) load() (map[cacheKey]entry, []error) {
+	entries := make(map[cacheKey]entry)
+
+	files, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return entries, []error{err}
+	}
+
+	var errs []error
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		key, e, err := s.read(filepath.Join(s.dir, f.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries[key] = e
+	}
+	return entries, errs
+}
+
+func (s *store) read(path string) (cacheKey, entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cacheKey{}, entry{}, err
+	}
+
+	var se storedEntry
+	if err := json.Unmarshal(b, &se); err != nil {
+		return cacheKey{}, entry{}, fmt.Errorf("unable to read cache file %s: %w", path, err)
+	}
+
+	if se.Version != storeVersion {
+		return cacheKey{}, entry{}, fmt.Errorf("unable to read cache file %s: version %d, expected %d", path, se.Version, storeVersion)
+	}
+
+	v, err := decodeValue(se.Kind, se.Value)
+	if err != nil {
+		return cacheKey{}, entry{}, fmt.Errorf("unable to read cache file %s: %w", path, err)
+	}
+
+	return cacheKey{se.Kind, se.Region}, entry{
+		fetched: se.Fetched,
+		expires: se.Expires,
+		value:   v,
+	}, nil
+}
+
+// decodeValue decodes a stored value into the type the cache holds for
+// its kind
+func decodeValue(kind string, raw json.RawMessage) (interface{}, error) {
+	switch kind {
+	case forecastKind:
+		var v Forecast
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+	return nil, fmt.Errorf("unknown kind %q", kind)
+}
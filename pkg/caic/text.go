@@ -0,0 +1,32 @@
+package caic
+
+import "time"
+
+// ForecastText is the forecaster's written summary of a zone forecast
+type ForecastText struct {
+	Region         Region
+	IssuedBy       string
+	BottomLine     string
+	TravelAdvice   string
+	Discussion     string
+	WeatherSummary string
+	Issued         time.Time
+	Expires        time.Time
+}
+
+// textFrom reads the written forecast from the page. Sections the page
+// doesn't have are left empty.
+func textFrom(p page) ForecastText {
+	doc := p.Document
+	issued, expires := forecastTimes(doc)
+	return ForecastText{
+		Region:         p.region,
+		IssuedBy:       textFor(doc, "#forecaster"),
+		BottomLine:     textFor(doc, "#bottom-line"),
+		TravelAdvice:   textFor(doc, "#travel-advice"),
+		Discussion:     textFor(doc, "#forecast-discussion"),
+		WeatherSummary: textFor(doc, "#weather-summary"),
+		Issued:         issued,
+		Expires:        expires,
+	}
+}
@@ -0,0 +1,87 @@
+package caic_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetForecastText(t *testing.T) {
+	t.Run("it returns the forecaster's text for the region", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecastText
+
+		text, err := tc.cache.ForecastText(context.Background(), caic.Aspen)
+		require.Nil(t, err)
+
+		require.Equal(t, baseURL+"/caic/pub_bc_avo.php?zone_id=4", tc.fakeHttp.reqs[0].URL.String())
+		require.Equal(t, http.MethodGet, tc.fakeHttp.reqs[0].Method)
+		require.Equal(
+			t,
+			caic.ForecastText{
+				Region:         caic.Aspen,
+				IssuedBy:       "Jane Forecaster",
+				BottomLine:     "Avoid wind loaded slopes near and above treeline.",
+				TravelAdvice:   "Stick to slopes under 30 degrees.",
+				Discussion:     "A buried weak layer continues to produce large avalanches.",
+				WeatherSummary: "Light snow with strong westerly winds.",
+			},
+			text,
+		)
+	})
+
+	t.Run("it returns empty text when the forecast has none", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecast
+
+		text, err := tc.cache.ForecastText(context.Background(), caic.Aspen)
+		require.Nil(t, err)
+		require.Equal(t, caic.ForecastText{Region: caic.Aspen}, text)
+	})
+
+	t.Run("it keeps paragraphs apart", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- `<div id="bottom-line"><p>Avoid wind loaded slopes.</p>
+			<p>Avoid steep slopes below cornices.</p></div>`
+
+		text, err := tc.cache.ForecastText(context.Background(), caic.Aspen)
+		require.Nil(t, err)
+		require.Equal(t, "Avoid wind loaded slopes.\n\nAvoid steep slopes below cornices.", text.BottomLine)
+	})
+
+	t.Run("there's no text for the entire state", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+
+		text, err := tc.cache.ForecastText(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+		require.Equal(t, caic.ForecastText{Region: caic.EntireState}, text)
+	})
+
+	t.Run("it returns an error when the request fails", func(t *testing.T) {
+		tc := setup(http.StatusNotFound, nil)
+
+		_, err := tc.cache.ForecastText(context.Background(), caic.Aspen)
+		require.NotNil(t, err)
+	})
+}
+
+var forecastText = `
+<div id="avalanche-forecast">
+	<div class="forecaster">Issued by <span id="forecaster">Jane Forecaster</span></div>
+	<div id="bottom-line">
+		<p>Avoid wind loaded slopes near and above treeline.</p>
+	</div>
+	<div id="travel-advice">
+		<p>Stick to slopes under 30 degrees.</p>
+	</div>
+	<div id="forecast-discussion">
+		<p>A buried weak layer continues to produce large avalanches.</p>
+	</div>
+	<div id="weather-summary">
+		<p>Light snow with strong westerly winds.</p>
+	</div>
+</div>
+`
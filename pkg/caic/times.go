@@ -0,0 +1,40 @@
+package caic
+
+import (
+	"strings"
+	"time"
+	_ "time/tzdata" // CAIC publishes in Mountain time, make sure it's available everywhere
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const forecastTimeLayout = "1/2/2006 3:04 PM"
+
+var denver = mustLoadLocation("America/Denver")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// forecastTimes returns when the forecast was issued and when it expires.
+// Missing or unreadable times are returned as the zero time.
+func forecastTimes(doc *goquery.Document) (time.Time, time.Time) {
+	return parseForecastTime(textFor(doc, "#forecast-issued")), parseForecastTime(textFor(doc, "#forecast-expires"))
+}
+
+// parseForecastTime parses times like "Issued: 4/14/2021 4:30 PM" in Mountain time
+func parseForecastTime(s string) time.Time {
+	if i := strings.IndexAny(s, "0123456789"); i > 0 {
+		s = s[i:]
+	}
+
+	t, err := time.ParseInLocation(forecastTimeLayout, strings.Join(strings.Fields(s), " "), denver)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -1,10 +1,12 @@
 package caic
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -16,6 +18,15 @@ type Zone struct {
 	AboveTreeline int
 	NearTreeline  int
 	BelowTreeline int
+
+	// Tomorrow's outlook from the same forecast
+	TomorrowRating        int
+	TomorrowAboveTreeline int
+	TomorrowNearTreeline  int
+	TomorrowBelowTreeline int
+
+	Issued  time.Time
+	Expires time.Time
 }
 
 type elevation int
@@ -26,53 +37,56 @@ const (
 	belowTreeline
 )
 
-func (c *Client) Summary(r Region) ([]Zone, error) {
-	if r == EntireState {
-		return c.stateSummary()
-	}
-	return c.singleRegionSummary(r)
+type day int
+
+const (
+	today day = iota
+	tomorrow
+)
+
+// The treeline table has a today and a tomorrow cell for each elevation
+var dayCells = map[day]string{
+	today:    "td.today-text",
+	tomorrow: `td[class*="tomorrow_danger"]`,
 }
 
-func (c *Client) CanConnect() bool {
-	_, err := c.doRequest(homePath)
+func (c *Client) CanConnect(ctx context.Context) bool {
+	_, err := c.doRequest(ctx, homePath)
 	return err == nil
 }
 
-func (c *Client) stateSummary() ([]Zone, error) {
-	var zones []Zone
-	for i := SteamboatFlatTops; i <= SangreDeCristo; i++ {
-		z, err := c.singleRegionSummary(Region(i))
-		if err != nil {
-			return nil, err
-		}
-		zones = append(zones, z...)
+// zoneFrom reads the ratings for today and tomorrow from the page
+func zoneFrom(p page) (Zone, error) {
+	z := Zone{
+		Index: p.region,
+		Name:  p.region.String(),
 	}
 
-	return zones, nil
-}
-
-func (c *Client) singleRegionSummary(r Region) ([]Zone, error) {
-	path := fmt.Sprintf(regionPath, r)
-	resp, err := c.doRequest(path)
-	if err != nil {
-		return nil, err
+	ratings := []struct {
+		rating *int
+		e      elevation
+		d      day
+	}{
+		{&z.AboveTreeline, aboveTreeline, today},
+		{&z.NearTreeline, nearTreeline, today},
+		{&z.BelowTreeline, belowTreeline, today},
+		{&z.TomorrowAboveTreeline, aboveTreeline, tomorrow},
+		{&z.TomorrowNearTreeline, nearTreeline, tomorrow},
+		{&z.TomorrowBelowTreeline, belowTreeline, tomorrow},
 	}
-
-	doc, err := toDocument(resp)
-	if err != nil {
-		return nil, err
+	for _, rt := range ratings {
+		var err error
+		*rt.rating, err = ratingFor(rt.e, rt.d, p)
+		if err != nil {
+			return Zone{}, err
+		}
 	}
 
-	z := Zone{
-		Index:         r,
-		Name:          r.String(),
-		AboveTreeline: ratingFor(aboveTreeline, doc),
-		NearTreeline:  ratingFor(nearTreeline, doc),
-		BelowTreeline: ratingFor(belowTreeline, doc),
-	}
+	z.Issued, z.Expires = forecastTimes(p.Document)
 	z.Rating = max(z.AboveTreeline, z.NearTreeline, z.BelowTreeline)
+	z.TomorrowRating = max(z.TomorrowAboveTreeline, z.TomorrowNearTreeline, z.TomorrowBelowTreeline)
 
-	return []Zone{z}, nil
+	return z, nil
 }
 
 func toDocument(s string) (*goquery.Document, error) {
@@ -85,11 +99,19 @@ func toDocument(s string) (*goquery.Document, error) {
 	return doc, nil
 }
 
-func ratingFor(e elevation, doc *goquery.Document) int {
-	query := fmt.Sprintf("#avalanche-forecast > table.table.table-striped-body.table-treeline > tbody > tr:nth-child(%d) > td.today-text > strong", e)
-	ratingText := doc.Find(query).Nodes[0].FirstChild.Data
+func ratingFor(e elevation, d day, p page) (int, error) {
+	query := fmt.Sprintf("#avalanche-forecast > table.table.table-striped-body.table-treeline > tbody > tr:nth-child(%d) > %s > strong", e, dayCells[d])
+	s, err := p.find(query)
+	if err != nil {
+		return 0, err
+	}
+
+	node := s.Nodes[0].FirstChild
+	if node == nil {
+		return 0, p.parseError(query + " text")
+	}
 
-	return parseRating(ratingText)
+	return parseRating(node.Data), nil
 }
 
 func parseRating(s string) int {
@@ -2,10 +2,13 @@ package caic_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/grafana/caic-datasource/pkg/caic"
 	"github.com/stretchr/testify/require"
@@ -14,26 +17,33 @@ import (
 func TestClientCanConnect(t *testing.T) {
 	t.Run("it returns true when it can connect", func(t *testing.T) {
 		tc := setup(http.StatusOK, nil)
-		require.True(t, tc.caicClient.CanConnect())
+		require.True(t, tc.caicClient.CanConnect(context.Background()))
 	})
 
 	t.Run("return false when it gets a non 200", func(t *testing.T) {
 		tc := setup(http.StatusBadGateway, nil)
-		require.False(t, tc.caicClient.CanConnect())
+		require.False(t, tc.caicClient.CanConnect(context.Background()))
 	})
 
 	t.Run("return false when the client has an error", func(t *testing.T) {
 		tc := setup(http.StatusOK, errors.New("something bad happened"))
-		require.False(t, tc.caicClient.CanConnect())
+		require.False(t, tc.caicClient.CanConnect(context.Background()))
 	})
 }
 
+func TestRegionString(t *testing.T) {
+	require.Equal(t, "Front Range", caic.FrontRange.String())
+	require.Equal(t, "Entire State", caic.EntireState.String())
+	require.Equal(t, "Region(42)", caic.Region(42).String())
+	require.Equal(t, "Region(-2)", caic.Region(-2).String())
+}
+
 func TestGetRegionSummary(t *testing.T) {
 	t.Run("returns the forecast by elevation for a single zone", func(t *testing.T) {
 		tc := setup(http.StatusOK, nil)
 		tc.fakeHttp.resp <- forecast
 
-		zone, _ := tc.caicClient.Summary(caic.SteamboatFlatTops)
+		zone, _ := tc.cache.Summary(context.Background(), caic.SteamboatFlatTops)
 		require.Equal(t, baseURL+"/caic/pub_bc_avo.php?zone_id=0", tc.fakeHttp.reqs[0].URL.String())
 		require.Equal(t, http.MethodGet, tc.fakeHttp.reqs[0].Method)
 
@@ -48,6 +58,11 @@ func TestGetRegionSummary(t *testing.T) {
 					AboveTreeline: 3,
 					NearTreeline:  2,
 					BelowTreeline: 4,
+
+					TomorrowRating:        1,
+					TomorrowAboveTreeline: 1,
+					TomorrowNearTreeline:  1,
+					TomorrowBelowTreeline: 1,
 				},
 			})
 	})
@@ -56,7 +71,7 @@ func TestGetRegionSummary(t *testing.T) {
 		tc := setup(http.StatusOK, nil)
 		tc.fakeHttp.resp <- forecastWithNoRating
 
-		zone, _ := tc.caicClient.Summary(caic.SteamboatFlatTops)
+		zone, _ := tc.cache.Summary(context.Background(), caic.SteamboatFlatTops)
 		require.Equal(t, baseURL+"/caic/pub_bc_avo.php?zone_id=0", tc.fakeHttp.reqs[0].URL.String())
 		require.Equal(t, http.MethodGet, tc.fakeHttp.reqs[0].Method)
 
@@ -71,10 +86,54 @@ func TestGetRegionSummary(t *testing.T) {
 					AboveTreeline: 0,
 					NearTreeline:  2,
 					BelowTreeline: 4,
+
+					TomorrowRating:        1,
+					TomorrowAboveTreeline: 1,
+					TomorrowNearTreeline:  1,
+					TomorrowBelowTreeline: 1,
 				},
 			})
 	})
 
+	t.Run("it returns tomorrow's outlook", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecastWithTimes
+
+		zones, err := tc.cache.Summary(context.Background(), caic.SteamboatFlatTops)
+		require.Nil(t, err)
+
+		require.Equal(t, 3, zones[0].Rating)
+		require.Equal(t, 2, zones[0].TomorrowRating)
+		require.Equal(t, 2, zones[0].TomorrowAboveTreeline)
+		require.Equal(t, 1, zones[0].TomorrowNearTreeline)
+		require.Equal(t, 0, zones[0].TomorrowBelowTreeline)
+	})
+
+	t.Run("it returns when the forecast was issued and when it expires", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecastWithTimes
+
+		zones, err := tc.cache.Summary(context.Background(), caic.SteamboatFlatTops)
+		require.Nil(t, err)
+
+		denver, err := time.LoadLocation("America/Denver")
+		require.Nil(t, err)
+
+		require.True(t, time.Date(2021, 4, 14, 16, 30, 0, 0, denver).Equal(zones[0].Issued))
+		require.True(t, time.Date(2021, 4, 15, 16, 30, 0, 0, denver).Equal(zones[0].Expires))
+	})
+
+	t.Run("it returns zero times when the forecast doesn't have them", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecast
+
+		zones, err := tc.cache.Summary(context.Background(), caic.SteamboatFlatTops)
+		require.Nil(t, err)
+
+		require.True(t, zones[0].Issued.IsZero())
+		require.True(t, zones[0].Expires.IsZero())
+	})
+
 	t.Run("it returns an array of state zones when region is EntireState", func(t *testing.T) {
 		tc := setup(http.StatusOK, nil)
 		tc.fakeHttp.resp <- forecast
@@ -89,62 +148,190 @@ func TestGetRegionSummary(t *testing.T) {
 		tc.fakeHttp.resp <- forecast
 
 		expected := []caic.Zone{
-			{Index: caic.SteamboatFlatTops, Name: caic.SteamboatFlatTops.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.FrontRange, Name: caic.FrontRange.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.VailSummitCounty, Name: caic.VailSummitCounty.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.SawatchRange, Name: caic.SawatchRange.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.Aspen, Name: caic.Aspen.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.Gunnison, Name: caic.Gunnison.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.GrandMesa, Name: caic.GrandMesa.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.NorthernSanJuan, Name: caic.NorthernSanJuan.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.SouthernSanJuan, Name: caic.SouthernSanJuan.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
-			{Index: caic.SangreDeCristo, Name: caic.SangreDeCristo.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4},
+			{Index: caic.SteamboatFlatTops, Name: caic.SteamboatFlatTops.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.FrontRange, Name: caic.FrontRange.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.VailSummitCounty, Name: caic.VailSummitCounty.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.SawatchRange, Name: caic.SawatchRange.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.Aspen, Name: caic.Aspen.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.Gunnison, Name: caic.Gunnison.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.GrandMesa, Name: caic.GrandMesa.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.NorthernSanJuan, Name: caic.NorthernSanJuan.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.SouthernSanJuan, Name: caic.SouthernSanJuan.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
+			{Index: caic.SangreDeCristo, Name: caic.SangreDeCristo.String(), Rating: 4, AboveTreeline: 3, NearTreeline: 2, BelowTreeline: 4, TomorrowRating: 1, TomorrowAboveTreeline: 1, TomorrowNearTreeline: 1, TomorrowBelowTreeline: 1},
 		}
 
-		zones, _ := tc.caicClient.Summary(caic.EntireState)
+		zones, _ := tc.cache.Summary(context.Background(), caic.EntireState)
 
-		require.Equal(t, baseURL+"/caic/pub_bc_avo.php?zone_id=0", tc.fakeHttp.reqs[0].URL.String())
-		require.Equal(t, baseURL+"/caic/pub_bc_avo.php?zone_id=9", tc.fakeHttp.reqs[9].URL.String())
-		require.Equal(t, http.MethodGet, tc.fakeHttp.reqs[0].Method)
+		// Regions are fetched concurrently so requests can be in any order
+		var urls []string
+		for _, r := range tc.fakeHttp.reqs {
+			require.Equal(t, http.MethodGet, r.Method)
+			urls = append(urls, r.URL.String())
+		}
+		require.Len(t, urls, 10)
+		require.Contains(t, urls, baseURL+"/caic/pub_bc_avo.php?zone_id=0")
+		require.Contains(t, urls, baseURL+"/caic/pub_bc_avo.php?zone_id=9")
 
 		require.Equal(t, expected, zones)
 	})
 
+	t.Run("it returns a ParseError instead of panicking when the ratings are missing", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- `<div id="avalanche-forecast"><p>No forecast this season</p></div>`
+
+		_, err := tc.cache.Summary(context.Background(), caic.FrontRange)
+
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(err, &parseErr))
+		require.Contains(t, parseErr.Selector, "tr:nth-child(1) > td.today-text")
+		require.Equal(t, caic.FrontRange, parseErr.Region)
+		require.Equal(t, "/caic/pub_bc_avo.php?zone_id=1", parseErr.Page)
+	})
+
+	t.Run("it returns a ParseError when tomorrow's outlook is missing", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecastWithoutTomorrow
+
+		_, err := tc.cache.Summary(context.Background(), caic.FrontRange)
+
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(err, &parseErr))
+		require.Contains(t, parseErr.Selector, "tomorrow_danger")
+	})
+
 	t.Run("it returns an error if the CAIC website can't be reached", func(t *testing.T) {
 		tc := setup(http.StatusNotFound, nil)
 
-		_, err := tc.caicClient.Summary(caic.EntireState)
+		_, err := tc.cache.Summary(context.Background(), caic.EntireState)
 		require.NotNil(t, err)
 	})
 }
 
+func TestGetStateSummary(t *testing.T) {
+	t.Run("it fetches regions concurrently up to the max concurrency", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil, caic.WithMaxConcurrency(3))
+		tc.fakeHttp.delay = 10 * time.Millisecond
+		for i := 0; i < 10; i++ {
+			tc.fakeHttp.resp <- forecast
+		}
+
+		zones, err := tc.cache.Summary(context.Background(), caic.EntireState)
+		require.Nil(t, err)
+
+		require.Len(t, zones, 10)
+		require.Equal(t, 3, tc.fakeHttp.maxInFlight)
+	})
+
+	t.Run("it returns partial results with the regions that failed", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.statusFor["zone_id=4"] = http.StatusBadGateway
+		tc.fakeHttp.statusFor["zone_id=7"] = http.StatusServiceUnavailable
+		for i := 0; i < 10; i++ {
+			tc.fakeHttp.resp <- forecast
+		}
+
+		zones, err := tc.cache.Summary(context.Background(), caic.EntireState)
+		require.Len(t, zones, 8)
+		for _, z := range zones {
+			require.NotEqual(t, caic.Aspen, z.Index)
+			require.NotEqual(t, caic.NorthernSanJuan, z.Index)
+		}
+
+		var partialErr *caic.PartialError
+		require.True(t, errors.As(err, &partialErr))
+		require.Len(t, partialErr.Errors, 2)
+		require.Equal(t, caic.Aspen, partialErr.Errors[0].Region)
+		require.Equal(t, caic.NorthernSanJuan, partialErr.Errors[1].Region)
+		require.Contains(t, err.Error(), "unable to fetch 2 of 10 regions")
+	})
+}
+
+func TestRequestContext(t *testing.T) {
+	t.Run("it sends requests with the caller's context", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		tc.fakeHttp.resp <- forecast
+
+		deadline := time.Now().Add(time.Minute)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+
+		_, err := tc.caicClient.Forecast(ctx, caic.FrontRange)
+		require.Nil(t, err)
+
+		reqDeadline, ok := tc.fakeHttp.reqs[0].Context().Deadline()
+		require.True(t, ok)
+		require.Equal(t, deadline, reqDeadline)
+	})
+
+	t.Run("it returns the context's error when it is cancelled", func(t *testing.T) {
+		tc := setup(http.StatusOK, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := tc.caicClient.Forecast(ctx, caic.FrontRange)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Empty(t, tc.fakeHttp.reqs)
+
+		require.False(t, tc.caicClient.CanConnect(ctx))
+	})
+}
+
 type testContext struct {
 	fakeHttp   *spyHttpClient
 	caicClient *caic.Client
+	cache      *caic.Cache // serves each part from caicClient's forecasts
 }
 
-func setup(responseCode int, httpError error) testContext {
+func setup(responseCode int, httpError error, opts ...caic.ClientOption) testContext {
 	fakeHttp := &spyHttpClient{
-		resp:     make(chan string, 10),
-		respCode: responseCode,
-		err:      httpError,
+		resp:      make(chan string, 10),
+		respCode:  responseCode,
+		statusFor: make(map[string]int),
+		err:       httpError,
 	}
 
+	client := caic.NewClient(baseURL, fakeHttp, opts...)
 	return testContext{
 		fakeHttp:   fakeHttp,
-		caicClient: caic.NewClient(baseURL, fakeHttp),
+		caicClient: client,
+		cache:      caic.NewClientCache(client),
 	}
 }
 
 type spyHttpClient struct {
-	reqs     []*http.Request
-	resp     chan string
-	respCode int
-	err      error
+	mu          sync.Mutex
+	reqs        []*http.Request
+	resp        chan string
+	respCode    int
+	statusFor   map[string]int // status codes by request query
+	err         error
+	delay       time.Duration
+	inFlight    int
+	maxInFlight int
 }
 
 func (c *spyHttpClient) Do(r *http.Request) (*http.Response, error) {
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
 	c.reqs = append(c.reqs, r)
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	code, ok := c.statusFor[r.URL.RawQuery]
+	if !ok {
+		code = c.respCode
+	}
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
 
 	var resp string
 	select {
@@ -153,7 +340,7 @@ func (c *spyHttpClient) Do(r *http.Request) (*http.Response, error) {
 	}
 
 	return &http.Response{
-		StatusCode: c.respCode,
+		StatusCode: code,
 		Body:       ioutil.NopCloser(bytes.NewReader([]byte(resp))),
 	}, c.err
 }
@@ -192,6 +379,55 @@ var (
 	</table>
 </div>
 `
+	forecastWithTimes = `
+<div id="avalanche-forecast">
+	<div class="forecast-times">
+		<span id="forecast-issued">Issued: 4/14/2021  4:30 PM</span>
+		<span id="forecast-expires">Expires: 4/15/2021 4:30 PM</span>
+	</div>
+	<table class="table table-striped-body table-treeline">
+		<tbody>
+			<tr>
+				<td class="today-text above_danger_considerable">
+						<strong>Considerable (3)</strong>
+				</td>
+				<td class="tomorrow-text tomorrow_danger_moderate">
+						<strong>Moderate (2)</strong>
+				</td>
+			</tr>
+			<tr>
+				<td class="today-text near_danger_moderate">
+						<strong>Moderate (2)</strong>
+				</td>
+				<td class="tomorrow-text tomorrow_danger_low">
+						<strong>Low (1)</strong>
+				</td>
+			</tr>
+			<tr>
+				<td class="today-text below_danger_low">
+						<strong>Low (1)</strong>
+				</td>
+				<td class="tomorrow-text tomorrow_danger_none">
+						<strong>No Rating (-)</strong>
+				</td>
+			</tr>
+		</tbody>
+	</table>
+</div>
+`
+
+	forecastWithoutTomorrow = `
+<div id="avalanche-forecast">
+	<table class="table table-striped-body table-treeline">
+		<tbody>
+			<tr><td class="today-text above_danger_low"><strong>Low (1)</strong></td></tr>
+			<tr><td class="today-text near_danger_low"><strong>Low (1)</strong></td></tr>
+			<tr><td class="today-text below_danger_low"><strong>Low (1)</strong></td></tr>
+		</tbody>
+	</table>
+</div>
+`
+
 	forecastWithNoRating = `
 <div id="avalanche-forecast">
 	<table class="table table-striped-body table-treeline">
@@ -5,9 +5,12 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/grafana/caic-datasource/pkg/archive"
 	"github.com/grafana/caic-datasource/pkg/caic"
 	"github.com/grafana/caic-datasource/pkg/plugin"
+	"github.com/grafana/caic-datasource/pkg/provider"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
@@ -30,14 +33,67 @@ func main() {
 
 //TODO For this to work with the standalone stuff, the plugin needs
 func constructor(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
-	caicURL := os.Getenv("CAIC_ADDR")
+	s, err := plugin.LoadSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// The URL in the settings wins over the environment
+	caicURL := s.URL
+	if caicURL == "" {
+		caicURL = os.Getenv("CAIC_ADDR")
+	}
 	if caicURL == "" {
 		caicURL = "https://www.avalanche.state.co.us"
 	}
 
-	client := caic.NewClient(caicURL, http.DefaultClient)
-	cache := caic.NewClientCache(client)
-	return &plugin.Handler{
-		Client: cache,
-	}, nil
+	// CAIC's server blips during the morning rush, so failed requests are
+	// retried. Every datasource shares one rate limit, which every retry
+	// waits for too, and requests stop for a while when CAIC keeps failing,
+	// so we don't get blocked.
+	retries := []caic.RetryOption{
+		caic.WithAttemptTimeout(s.Timeout),
+		caic.WithRateLimiter(caic.SharedRateLimiter()),
+	}
+	var d caic.Doer = caic.NewRetryDoer(&http.Client{}, retries...)
+	d = caic.NewCircuitBreakerDoer(d, 5, time.Minute)
+	d = caic.NewUserAgentDoer(d, s.UserAgent)
+
+	// Fetches get long enough for every retry
+	fetchTimeout := caic.RetryDuration(retries...)
+
+	opts := []caic.CacheOption{caic.WithRefreshTimeout(fetchTimeout)}
+	if s.CachePath != "" {
+		opts = append(opts, caic.WithStore(s.CachePath))
+	}
+	if s.CacheDuration > 0 {
+		opts = append(opts, caic.WithCacheDuration(s.CacheDuration))
+	}
+
+	var client caic.Source = caic.NewClient(caicURL, d, caic.WithMaxConcurrency(s.MaxConcurrency))
+	if s.Source == plugin.SourceJSON {
+		client = caic.NewJSONClient(s.APIURL, d, caic.WithFetchTimeout(fetchTimeout))
+	}
+
+	h := &plugin.Handler{}
+	if s.ArchivePath != "" {
+		a, err := archive.Open(s.ArchivePath)
+		if err != nil {
+			return nil, err
+		}
+		client = archive.NewRecorder(client, a)
+		h.Archive = a
+	}
+
+	cache := caic.NewClientCache(client, opts...)
+	h.Client = cache
+
+	// Every center the plugin knows, the datasource only queries the ones
+	// in its settings
+	centers := provider.NewRegistry(provider.NewCAIC(cache))
+	h.Providers, err = centers.Select(s.Centers)
+	if err != nil {
+		return nil, fmt.Errorf("bad datasource settings: %w", err)
+	}
+	return h, nil
 }
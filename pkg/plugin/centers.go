@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/caic-datasource/pkg/provider"
+)
+
+// provider returns the provider for the center a query asked for. Queries
+// saved before there were centers are for CAIC.
+func (h *Handler) provider(center string) (provider.Provider, error) {
+	if center == "" {
+		center = provider.CAICID
+	}
+
+	if h.Providers != nil {
+		if p, ok := h.Providers.Get(center); ok {
+			return p, nil
+		}
+	} else if center == provider.CAICID {
+		return provider.NewCAIC(h.Client), nil
+	}
+	return nil, errors.New(fmt.Sprint("bad query: center ", center, " isn't enabled in the datasource settings"))
+}
+
+// providers returns every center the datasource can query
+func (h *Handler) providers() []provider.Provider {
+	if h.Providers == nil {
+		return []provider.Provider{provider.NewCAIC(h.Client)}
+	}
+	return h.Providers.Providers()
+}
+
+// zoneNames maps the IDs of the provider's zones, and AllZones, to their
+// names
+func zoneNames(p provider.Provider) map[provider.ZoneID]string {
+	names := map[provider.ZoneID]string{provider.AllZones: p.Center().AllZones}
+	for _, z := range p.Zones() {
+		names[z.ID] = z.Name
+	}
+	return names
+}
@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/provider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// queryGeo returns the Geo frame, the zone ratings with each region's
+// centroid, for the Geomap panel
+func (h *Handler) queryGeo(ctx context.Context, q zoneQuery) backend.DataResponse {
+	zones, err := h.summaries(ctx, q.provider, q.regions)
+	d, err := degraded(err, len(zones))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	centroids := make(map[provider.ZoneID]provider.Zone)
+	for _, z := range q.provider.Zones() {
+		centroids[z.ID] = z
+	}
+
+	var regions []string
+	var rating []int64
+	var aboveTreeline []int64
+	var nearTreeline []int64
+	var belowTreeline []int64
+	var latitude []float64
+	var longitude []float64
+	var issued []time.Time
+	var expires []time.Time
+	var dayNames []string
+	for _, z := range zones {
+		centroid := centroids[z.Zone]
+		for _, day := range q.days {
+			r, above, near, below := ratingsFor(z, day)
+
+			regions = append(regions, z.Name)
+			rating = append(rating, int64(r))
+			aboveTreeline = append(aboveTreeline, int64(above))
+			nearTreeline = append(nearTreeline, int64(near))
+			belowTreeline = append(belowTreeline, int64(below))
+			latitude = append(latitude, centroid.Latitude)
+			longitude = append(longitude, centroid.Longitude)
+			issued = append(issued, z.Issued)
+			expires = append(expires, z.Expires)
+			dayNames = append(dayNames, day)
+		}
+	}
+
+	frame := data.NewFrame("Geo")
+	frame.Fields = append(frame.Fields, data.NewField("region", nil, regions))
+	frame.Fields = append(frame.Fields, data.NewField("rating", nil, rating))
+	frame.Fields = append(frame.Fields, data.NewField("aboveTreeline", nil, aboveTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("nearTreeline", nil, nearTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("belowTreeline", nil, belowTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("latitude", nil, latitude))
+	frame.Fields = append(frame.Fields, data.NewField("longitude", nil, longitude))
+	frame.Fields = append(frame.Fields, data.NewField("issued", nil, issued))
+	frame.Fields = append(frame.Fields, data.NewField("expires", nil, expires))
+	frame.Fields = append(frame.Fields, data.NewField("day", nil, dayNames))
+	frame.Meta = forecastMeta(issued, expires)
+	d.apply(frame)
+
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
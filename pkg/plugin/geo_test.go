@@ -0,0 +1,59 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/plugin"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryForGeo(t *testing.T) {
+	client := newFakeClient()
+	client.regionZones[caic.FrontRange] = []caic.Zone{{Index: caic.FrontRange, Name: "Front Range", Rating: 4, AboveTreeline: 4}}
+	client.regionZones[caic.Aspen] = []caic.Zone{{Index: caic.Aspen, Name: "Aspen", Rating: 2}}
+
+	h := &plugin.Handler{Client: client}
+	res, _ := h.QueryData(
+		context.Background(),
+		&backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					QueryType: "geo",
+					JSON:      []byte(`{"regions":[1,4]}`),
+				},
+			},
+		},
+	)
+
+	require.Nil(t, res.Responses["A"].Error)
+	frames := res.Responses["A"].Frames
+	require.Len(t, frames, 1)
+
+	frame := frames[0]
+	require.Equal(t, "Geo", frame.Name)
+	require.Equal(t, 2, frame.Rows())
+
+	var names []string
+	for _, f := range frame.Fields {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{
+		"region", "rating", "aboveTreeline", "nearTreeline", "belowTreeline",
+		"latitude", "longitude", "issued", "expires", "day",
+	}, names)
+
+	require.Equal(t, "Front Range", frame.At(0, 0))
+	require.Equal(t, int64(4), frame.At(1, 0))
+
+	lat, lon := caic.FrontRange.Centroid()
+	require.Equal(t, lat, frame.At(5, 0))
+	require.Equal(t, lon, frame.At(6, 0))
+
+	lat, lon = caic.Aspen.Centroid()
+	require.Equal(t, lat, frame.At(5, 1))
+	require.Equal(t, lon, frame.At(6, 1))
+}
@@ -5,104 +5,335 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/grafana/caic-datasource/pkg/archive"
 	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/provider"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 type caicClient interface {
-	CanConnect() bool
-	Summary(caic.Region) ([]caic.Zone, error)
-	AspectDanger(caic.Region) (caic.AspectDanger, error)
+	CanConnect(context.Context) bool
+	Summary(context.Context, caic.Region) ([]caic.Zone, error)
+	AspectDanger(context.Context, caic.Region) (caic.AspectDanger, error)
+	Problems(context.Context, caic.Region) ([]caic.AvalancheProblem, error)
+	ForecastText(context.Context, caic.Region) (caic.ForecastText, error)
+}
+
+type forecastArchive interface {
+	History(r caic.Region, from, to time.Time) []archive.Forecast
 }
 
 // Handles calls to QueryData and CheckHealth
 type Handler struct {
 	Client caicClient
+
+	// Archive answers history queries, which fail without one
+	Archive forecastArchive
+
+	// Providers are the avalanche centers queries can select. Without a
+	// registry, only CAIC can be queried, through Client.
+	Providers *provider.Registry
+}
+
+const (
+	today    = "today"
+	tomorrow = "tomorrow"
+	both     = "both"
+)
+
+// The forecast days each value of a query's day returns
+var forecastDays = map[string][]string{
+	"":       {today},
+	today:    {today},
+	tomorrow: {tomorrow},
+	both:     {today, tomorrow},
 }
 
-// Handles queries for CAIC Zone data
+// Handles queries for CAIC Zone data. Each query gets its own response so
+// a failed query doesn't fail the others in the request.
 func (h *Handler) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	qr := backend.NewQueryDataResponse()
+	for _, q := range req.Queries {
+		qr.Responses[q.RefID] = h.query(ctx, q)
+	}
+
+	return qr, nil
+}
+
+// The datasets a query can select with its query type
+const (
+	queryTypeSummary      = "summary"
+	queryTypeAspectDanger = "aspectDanger"
+	queryTypeProblems     = "problems"
+	queryTypeText         = "text"
+	queryTypeHistory      = "history"
+	queryTypeGeo          = "geo"
+)
+
+// zoneQuery is a query after it has been decoded and checked
+type zoneQuery struct {
+	provider  provider.Provider
+	regions   []provider.ZoneID
+	days      []string
+	format    string
+	timeRange backend.TimeRange
+}
+
+type queryHandler func(h *Handler, ctx context.Context, q zoneQuery) backend.DataResponse
+
+// The handler for each query type. Queries without one get every current
+// frame, as they did before there were query types.
+var queryHandlers = map[string]queryHandler{
+	"":                    (*Handler).queryAll,
+	queryTypeSummary:      (*Handler).querySummary,
+	queryTypeAspectDanger: (*Handler).queryAspectDanger,
+	queryTypeProblems:     (*Handler).queryProblemList,
+	queryTypeText:         (*Handler).queryText,
+	queryTypeHistory:      (*Handler).queryHistory,
+	queryTypeGeo:          (*Handler).queryGeo,
+}
+
+func (h *Handler) query(ctx context.Context, q backend.DataQuery) backend.DataResponse {
 	filter := struct {
-		Zone caic.Region `json:"zone"`
+		Center  string            `json:"center"`
+		Zone    provider.ZoneID   `json:"zone"`
+		Regions []provider.ZoneID `json:"regions"`
+		Day     string            `json:"day"`
+		Format  string            `json:"format"`
 	}{}
 
-	qr := backend.NewQueryDataResponse()
-	for _, q := range req.Queries {
-		err := json.Unmarshal(q.JSON, &filter)
-		if err != nil {
-			return nil, errors.New(fmt.Sprint("bad query: ", err.Error()))
-		}
+	err := json.Unmarshal(q.JSON, &filter)
+	if err != nil {
+		return errorResponse(errors.New(fmt.Sprint("bad query: ", err.Error())))
+	}
 
-		zoneFrame, err := h.queryZones(req, filter.Zone)
-		if err != nil {
-			return nil, err
-		}
+	handle, ok := queryHandlers[q.QueryType]
+	if !ok {
+		return errorResponse(errors.New(fmt.Sprint("bad query: unknown query type ", q.QueryType)))
+	}
 
-		problemFrame, err := h.queryProblems(req, filter.Zone)
-		if err != nil {
-			return nil, err
-		}
+	days, ok := forecastDays[filter.Day]
+	if !ok {
+		return errorResponse(errors.New(fmt.Sprint("bad query: unknown day ", filter.Day)))
+	}
 
-		resp := backend.DataResponse{}
-		resp.Frames = append(resp.Frames, zoneFrame)
-		resp.Frames = append(resp.Frames, problemFrame)
+	if !formats[filter.Format] {
+		return errorResponse(errors.New(fmt.Sprint("bad query: unknown format ", filter.Format)))
+	}
 
-		qr.Responses[q.RefID] = resp
+	p, err := h.provider(filter.Center)
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	return qr, nil
+	regions := queryRegions(filter.Zone, filter.Regions)
+	if err := checkRegions(p, regions); err != nil {
+		return errorResponse(err)
+	}
+
+	return handle(h, ctx, zoneQuery{
+		provider:  p,
+		regions:   regions,
+		days:      days,
+		format:    filter.Format,
+		timeRange: q.TimeRange,
+	})
 }
 
-func (h *Handler) queryZones(req *backend.QueryDataRequest, r caic.Region) (*data.Frame, error) {
-	zones, err := h.Client.Summary(r)
+// queryAll returns the Zones, AspectDanger, Problems and ForecastText
+// frames, or only the ratings when they're requested as a time series
+func (h *Handler) queryAll(ctx context.Context, q zoneQuery) backend.DataResponse {
+	if q.format == formatTimeSeries || q.format == formatLong {
+		return h.querySeries(ctx, q)
+	}
+
+	zoneFrame, err := h.queryZones(ctx, q.provider, q.regions, q.days)
 	if err != nil {
-		return nil, err
+		return errorResponse(err)
+	}
+
+	problemFrame, err := h.queryProblems(ctx, q.provider, q.regions)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	avalancheProblemFrame, err := h.queryAvalancheProblems(ctx, q.provider, q.regions)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	textFrame, err := h.queryForecastText(ctx, q.provider, q.regions)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	resp := backend.DataResponse{}
+	resp.Frames = append(resp.Frames, zoneFrame)
+	resp.Frames = append(resp.Frames, problemFrame)
+	resp.Frames = append(resp.Frames, avalancheProblemFrame)
+	resp.Frames = append(resp.Frames, textFrame)
+	return resp
+}
+
+func (h *Handler) querySummary(ctx context.Context, q zoneQuery) backend.DataResponse {
+	if q.format == formatTimeSeries || q.format == formatLong {
+		return h.querySeries(ctx, q)
+	}
+	return frameResponse(h.queryZones(ctx, q.provider, q.regions, q.days))
+}
+
+func (h *Handler) queryAspectDanger(ctx context.Context, q zoneQuery) backend.DataResponse {
+	return frameResponse(h.queryProblems(ctx, q.provider, q.regions))
+}
+
+func (h *Handler) queryProblemList(ctx context.Context, q zoneQuery) backend.DataResponse {
+	return frameResponse(h.queryAvalancheProblems(ctx, q.provider, q.regions))
+}
+
+func (h *Handler) queryText(ctx context.Context, q zoneQuery) backend.DataResponse {
+	return frameResponse(h.queryForecastText(ctx, q.provider, q.regions))
+}
+
+func frameResponse(frame *data.Frame, err error) backend.DataResponse {
+	if err != nil {
+		return errorResponse(err)
 	}
-	return h.createResponse(zones), nil
+	return backend.DataResponse{Frames: data.Frames{frame}}
 }
 
-func (h *Handler) queryProblems(req *backend.QueryDataRequest, r caic.Region) (*data.Frame, error) {
-	aspectDanger, err := h.Client.AspectDanger(r)
+func errorResponse(err error) backend.DataResponse {
+	return backend.DataResponse{Error: err}
+}
+
+// queryZones returns the Zones frame. When only some regions could be
+// fetched, the frame has the rest and a warning for each failure.
+func (h *Handler) queryZones(ctx context.Context, p provider.Provider, regions []provider.ZoneID, days []string) (*data.Frame, error) {
+	zones, err := h.summaries(ctx, p, regions)
+	d, err := degraded(err, len(zones))
 	if err != nil {
 		return nil, err
 	}
 
-	ordinals := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
-	degrees := []int32{0, 45, 90, 135, 180, 225, 270, 315}
+	frame := h.createResponse(zones, days)
+	d.apply(frame)
+	return frame, nil
+}
+
+// summaries fetches the zones of every region
+func (h *Handler) summaries(ctx context.Context, p provider.Provider, regions []provider.ZoneID) ([]provider.Summary, error) {
+	results := make([][]provider.Summary, len(regions))
+	err := fetchRegions(p, regions, func(i int, r provider.ZoneID) error {
+		z, err := p.Summary(ctx, r)
+		results[i] = z
+		return err
+	})
+
+	var zones []provider.Summary
+	for _, z := range results {
+		zones = append(zones, z...)
+	}
+	return zones, err
+}
+
+// degradation is what's wrong with results that came back with an error
+// that doesn't fail the query
+type degradation struct {
+	notices []data.Notice
+	stale   *provider.StaleError
+}
+
+// degraded separates partial results and stale cached data that couldn't
+// be refreshed, which are still shown, from errors that fail the query.
+// Partial results without any results fail the query.
+func degraded(err error, results int) (degradation, error) {
+	var d degradation
+
+	var partialErr *provider.PartialError
+	if errors.As(err, &partialErr) && results > 0 {
+		for _, re := range partialErr.Errors {
+			d.notices = append(d.notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     re.Error(),
+			})
+		}
+		return d, nil
+	}
+
+	if errors.As(err, &d.stale) {
+		// Data that has only just expired is stale until its first
+		// refresh finishes, which is no reason to warn that the center is down
+		if d.stale.Err == nil {
+			return degradation{}, nil
+		}
+		d.notices = append(d.notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprint("Showing forecast data fetched at ", d.stale.Fetched.Format(time.RFC1123), ", it could not be refreshed: ", d.stale.Err),
+		})
+		return d, nil
+	}
+
+	return d, err
+}
+
+func (d degradation) apply(frame *data.Frame) {
+	frame.AppendNotices(d.notices...)
+
+	if d.stale != nil {
+		fm, _ := frame.Meta.Custom.(ForecastMeta)
+		fm.Stale = true
+		fm.Fetched = d.stale.Fetched
+		frame.Meta.Custom = fm
+	}
+}
 
-	aboveTreeline := []int32{
-		toInt(aspectDanger.AboveTreeline.North),
-		toInt(aspectDanger.AboveTreeline.NorthEast),
-		toInt(aspectDanger.AboveTreeline.East),
-		toInt(aspectDanger.AboveTreeline.SouthEast),
-		toInt(aspectDanger.AboveTreeline.South),
-		toInt(aspectDanger.AboveTreeline.SouthWest),
-		toInt(aspectDanger.AboveTreeline.West),
-		toInt(aspectDanger.AboveTreeline.NorthWest),
+// queryProblems returns the AspectDanger frame with a row for each
+// ordinal of each region's rose
+func (h *Handler) queryProblems(ctx context.Context, p provider.Provider, regions []provider.ZoneID) (*data.Frame, error) {
+	var roses []provider.AspectDanger
+	results := make([]*provider.AspectDanger, len(regions))
+	err := fetchRegions(p, regions, func(i int, r provider.ZoneID) error {
+		ad, err := p.AspectDanger(ctx, r)
+		if usable(err) {
+			results[i] = &ad
+		}
+		return err
+	})
+	for _, ad := range results {
+		if ad != nil {
+			roses = append(roses, *ad)
+		}
 	}
 
-	nearTreeline := []int32{
-		toInt(aspectDanger.NearTreeline.North),
-		toInt(aspectDanger.NearTreeline.NorthEast),
-		toInt(aspectDanger.NearTreeline.East),
-		toInt(aspectDanger.NearTreeline.SouthEast),
-		toInt(aspectDanger.NearTreeline.South),
-		toInt(aspectDanger.NearTreeline.SouthWest),
-		toInt(aspectDanger.NearTreeline.West),
-		toInt(aspectDanger.NearTreeline.NorthWest),
+	d, err := degraded(err, len(roses))
+	if err != nil {
+		return nil, err
 	}
 
-	belowTreeline := []int32{
-		toInt(aspectDanger.BelowTreeline.North),
-		toInt(aspectDanger.BelowTreeline.NorthEast),
-		toInt(aspectDanger.BelowTreeline.East),
-		toInt(aspectDanger.BelowTreeline.SouthEast),
-		toInt(aspectDanger.BelowTreeline.South),
-		toInt(aspectDanger.BelowTreeline.SouthWest),
-		toInt(aspectDanger.BelowTreeline.West),
-		toInt(aspectDanger.BelowTreeline.NorthWest),
+	var ordinals []string
+	var degrees []int32
+	var aboveTreeline []int32
+	var nearTreeline []int32
+	var belowTreeline []int32
+	var issued []time.Time
+	var expires []time.Time
+	names := zoneNames(p)
+	var regionNames []string
+	for _, ad := range roses {
+		ordinals = append(ordinals, "N", "NE", "E", "SE", "S", "SW", "W", "NW")
+		degrees = append(degrees, 0, 45, 90, 135, 180, 225, 270, 315)
+		aboveTreeline = append(aboveTreeline, ordinalDanger(ad.AboveTreeline)...)
+		nearTreeline = append(nearTreeline, ordinalDanger(ad.NearTreeline)...)
+		belowTreeline = append(belowTreeline, ordinalDanger(ad.BelowTreeline)...)
+		issued = append(issued, repeatTime(ad.Issued, 8)...)
+		expires = append(expires, repeatTime(ad.Expires, 8)...)
+		for i := 0; i < 8; i++ {
+			regionNames = append(regionNames, names[ad.Zone])
+		}
 	}
 
 	frame := data.NewFrame("AspectDanger")
@@ -111,22 +342,227 @@ func (h *Handler) queryProblems(req *backend.QueryDataRequest, r caic.Region) (*
 	frame.Fields = append(frame.Fields, data.NewField("aboveTreeline", nil, aboveTreeline))
 	frame.Fields = append(frame.Fields, data.NewField("nearTreeline", nil, nearTreeline))
 	frame.Fields = append(frame.Fields, data.NewField("belowTreeline", nil, belowTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("issued", nil, issued))
+	frame.Fields = append(frame.Fields, data.NewField("expires", nil, expires))
+	frame.Fields = append(frame.Fields, data.NewField("region", nil, regionNames))
+	frame.Meta = forecastMeta(issued, expires)
+	d.apply(frame)
+
+	return frame, nil
+}
+
+// ordinalDanger returns whether each ordinal is a danger, in compass order
+func ordinalDanger(rose provider.Rose) []int32 {
+	return []int32{
+		toInt(rose.North),
+		toInt(rose.NorthEast),
+		toInt(rose.East),
+		toInt(rose.SouthEast),
+		toInt(rose.South),
+		toInt(rose.SouthWest),
+		toInt(rose.West),
+		toInt(rose.NorthWest),
+	}
+}
+
+func (h *Handler) queryAvalancheProblems(ctx context.Context, source provider.Provider, regions []provider.ZoneID) (*data.Frame, error) {
+	results := make([][]provider.Problem, len(regions))
+	err := fetchRegions(source, regions, func(i int, r provider.ZoneID) error {
+		p, err := source.Problems(ctx, r)
+		results[i] = p
+		return err
+	})
+
+	var problems []provider.Problem
+	for _, p := range results {
+		problems = append(problems, p...)
+	}
+
+	d, err := degraded(err, len(problems))
+	if err != nil {
+		return nil, err
+	}
+
+	names := zoneNames(source)
+	var regionNames []string
+	var types []string
+	var likelihoods []string
+	var minSizes []string
+	var maxSizes []string
+	var aboveTreeline []string
+	var nearTreeline []string
+	var belowTreeline []string
+	var issued []time.Time
+	var expires []time.Time
+	for _, p := range problems {
+		regionNames = append(regionNames, names[p.Zone])
+		types = append(types, p.Type)
+		likelihoods = append(likelihoods, p.Likelihood)
+		minSizes = append(minSizes, p.MinSize)
+		maxSizes = append(maxSizes, p.MaxSize)
+		aboveTreeline = append(aboveTreeline, strings.Join(p.AboveTreeline.Aspects(), ","))
+		nearTreeline = append(nearTreeline, strings.Join(p.NearTreeline.Aspects(), ","))
+		belowTreeline = append(belowTreeline, strings.Join(p.BelowTreeline.Aspects(), ","))
+		issued = append(issued, p.Issued)
+		expires = append(expires, p.Expires)
+	}
+
+	frame := data.NewFrame("Problems")
+	frame.Fields = append(frame.Fields, data.NewField("region", nil, regionNames))
+	frame.Fields = append(frame.Fields, data.NewField("type", nil, types))
+	frame.Fields = append(frame.Fields, data.NewField("likelihood", nil, likelihoods))
+	frame.Fields = append(frame.Fields, data.NewField("minSize", nil, minSizes))
+	frame.Fields = append(frame.Fields, data.NewField("maxSize", nil, maxSizes))
+	frame.Fields = append(frame.Fields, data.NewField("aboveTreeline", nil, aboveTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("nearTreeline", nil, nearTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("belowTreeline", nil, belowTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("issued", nil, issued))
+	frame.Fields = append(frame.Fields, data.NewField("expires", nil, expires))
+	frame.Meta = forecastMeta(issued, expires)
+	d.apply(frame)
+	return frame, nil
+}
+
+// queryForecastText returns the ForecastText frame with a row for each
+// region
+func (h *Handler) queryForecastText(ctx context.Context, p provider.Provider, regions []provider.ZoneID) (*data.Frame, error) {
+	results := make([]*provider.Text, len(regions))
+	err := fetchRegions(p, regions, func(i int, r provider.ZoneID) error {
+		text, err := p.Text(ctx, r)
+		if usable(err) {
+			results[i] = &text
+		}
+		return err
+	})
+
+	var texts []provider.Text
+	for _, text := range results {
+		if text != nil {
+			texts = append(texts, *text)
+		}
+	}
+
+	d, err := degraded(err, len(texts))
+	if err != nil {
+		return nil, err
+	}
 
+	names := zoneNames(p)
+	var regionNames []string
+	var issuedBy []string
+	var bottomLine []string
+	var travelAdvice []string
+	var discussion []string
+	var weatherSummary []string
+	var issued []time.Time
+	var expires []time.Time
+	for _, text := range texts {
+		regionNames = append(regionNames, names[text.Zone])
+		issuedBy = append(issuedBy, text.IssuedBy)
+		bottomLine = append(bottomLine, text.BottomLine)
+		travelAdvice = append(travelAdvice, text.TravelAdvice)
+		discussion = append(discussion, text.Discussion)
+		weatherSummary = append(weatherSummary, text.WeatherSummary)
+		issued = append(issued, text.Issued)
+		expires = append(expires, text.Expires)
+	}
+
+	frame := data.NewFrame("ForecastText")
+	frame.Fields = append(frame.Fields, data.NewField("region", nil, regionNames))
+	frame.Fields = append(frame.Fields, data.NewField("issuedBy", nil, issuedBy))
+	frame.Fields = append(frame.Fields, data.NewField("bottomLine", nil, bottomLine))
+	frame.Fields = append(frame.Fields, data.NewField("travelAdvice", nil, travelAdvice))
+	frame.Fields = append(frame.Fields, data.NewField("discussion", nil, discussion))
+	frame.Fields = append(frame.Fields, data.NewField("weatherSummary", nil, weatherSummary))
+	frame.Fields = append(frame.Fields, data.NewField("issued", nil, issued))
+	frame.Fields = append(frame.Fields, data.NewField("expires", nil, expires))
+	frame.Meta = forecastMeta(issued, expires)
+	d.apply(frame)
 	return frame, nil
 }
 
-func (h *Handler) createResponse(zones []caic.Zone) *data.Frame {
+// queryHistory returns the archived forecasts over the time range as a
+// History frame with a row for each region's forecast
+func (h *Handler) queryHistory(_ context.Context, q zoneQuery) backend.DataResponse {
+	if h.Archive == nil {
+		return errorResponse(errors.New("bad query: history needs an archive path in the datasource settings"))
+	}
+	if id := q.provider.Center().ID; id != provider.CAICID {
+		return errorResponse(errors.New(fmt.Sprint("bad query: history isn't archived for center ", id)))
+	}
+
+	var issued []time.Time
+	var regions []string
+	var rating []int64
+	var aboveTreeline []int64
+	var nearTreeline []int64
+	var belowTreeline []int64
+	var aboveTreelineAspects []string
+	var nearTreelineAspects []string
+	var belowTreelineAspects []string
+	var expires []time.Time
+	// The archive keeps CAIC's forecasts by region, the IDs of its zones
+	var history []archive.Forecast
+	for _, id := range q.regions {
+		history = append(history, h.Archive.History(caic.Region(id), q.timeRange.From, q.timeRange.To)...)
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Issued.Before(history[j].Issued)
+	})
+
+	names := zoneNames(q.provider)
+	for _, f := range history {
+		issued = append(issued, f.Issued)
+		regions = append(regions, names[provider.ZoneID(f.Region)])
+		rating = append(rating, int64(f.Rating))
+		aboveTreeline = append(aboveTreeline, int64(f.AboveTreeline))
+		nearTreeline = append(nearTreeline, int64(f.NearTreeline))
+		belowTreeline = append(belowTreeline, int64(f.BelowTreeline))
+		aboveTreelineAspects = append(aboveTreelineAspects, strings.Join(f.AboveTreelineAspects.Aspects(), ","))
+		nearTreelineAspects = append(nearTreelineAspects, strings.Join(f.NearTreelineAspects.Aspects(), ","))
+		belowTreelineAspects = append(belowTreelineAspects, strings.Join(f.BelowTreelineAspects.Aspects(), ","))
+		expires = append(expires, f.Expires)
+	}
+
+	frame := data.NewFrame("History")
+	frame.Fields = append(frame.Fields, data.NewField("issued", nil, issued))
+	frame.Fields = append(frame.Fields, data.NewField("region", nil, regions))
+	frame.Fields = append(frame.Fields, data.NewField("rating", nil, rating))
+	frame.Fields = append(frame.Fields, data.NewField("aboveTreeline", nil, aboveTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("nearTreeline", nil, nearTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("belowTreeline", nil, belowTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("aboveTreelineAspects", nil, aboveTreelineAspects))
+	frame.Fields = append(frame.Fields, data.NewField("nearTreelineAspects", nil, nearTreelineAspects))
+	frame.Fields = append(frame.Fields, data.NewField("belowTreelineAspects", nil, belowTreelineAspects))
+	frame.Fields = append(frame.Fields, data.NewField("expires", nil, expires))
+
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// createResponse builds the Zones frame with a row for each zone and
+// requested forecast day
+func (h *Handler) createResponse(zones []provider.Summary, days []string) *data.Frame {
 	var names []string
 	var rating []int64
 	var aboveTreeline []int64
 	var nearTreeline []int64
 	var belowTreeline []int64
+	var issued []time.Time
+	var expires []time.Time
+	var dayNames []string
 	for _, z := range zones {
-		names = append(names, z.Name)
-		rating = append(rating, int64(z.Rating))
-		aboveTreeline = append(aboveTreeline, int64(z.AboveTreeline))
-		nearTreeline = append(nearTreeline, int64(z.NearTreeline))
-		belowTreeline = append(belowTreeline, int64(z.BelowTreeline))
+		for _, d := range days {
+			r, above, near, below := ratingsFor(z, d)
+
+			names = append(names, z.Name)
+			rating = append(rating, int64(r))
+			aboveTreeline = append(aboveTreeline, int64(above))
+			nearTreeline = append(nearTreeline, int64(near))
+			belowTreeline = append(belowTreeline, int64(below))
+			issued = append(issued, z.Issued)
+			expires = append(expires, z.Expires)
+			dayNames = append(dayNames, d)
+		}
 	}
 
 	frame := data.NewFrame("Zones")
@@ -135,15 +571,30 @@ func (h *Handler) createResponse(zones []caic.Zone) *data.Frame {
 	frame.Fields = append(frame.Fields, data.NewField("aboveTreeline", nil, aboveTreeline))
 	frame.Fields = append(frame.Fields, data.NewField("nearTreeline", nil, nearTreeline))
 	frame.Fields = append(frame.Fields, data.NewField("belowTreeline", nil, belowTreeline))
+	frame.Fields = append(frame.Fields, data.NewField("issued", nil, issued))
+	frame.Fields = append(frame.Fields, data.NewField("expires", nil, expires))
+	frame.Fields = append(frame.Fields, data.NewField("day", nil, dayNames))
+	frame.Meta = forecastMeta(issued, expires)
 	return frame
 }
 
+// ratingsFor returns the overall, above, near and below treeline ratings
+// of the zone for the forecast day
+func ratingsFor(z provider.Summary, day string) (int, int, int, int) {
+	if day == tomorrow {
+		return z.TomorrowRating, z.TomorrowAboveTreeline, z.TomorrowNearTreeline, z.TomorrowBelowTreeline
+	}
+	return z.Rating, z.AboveTreeline, z.NearTreeline, z.BelowTreeline
+}
+
 func (h *Handler) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
-	if !h.Client.CanConnect() {
-		return &backend.CheckHealthResult{
-			Status:  backend.HealthStatusError,
-			Message: "Error reaching CAIC site",
-		}, nil
+	for _, p := range h.providers() {
+		if !p.CanConnect(ctx) {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: fmt.Sprint("Error reaching ", p.Center().Name, " site"),
+			}, nil
+		}
 	}
 
 	return &backend.CheckHealthResult{
@@ -152,6 +603,54 @@ func (h *Handler) CheckHealth(ctx context.Context, req *backend.CheckHealthReque
 	}, nil
 }
 
+// ForecastMeta is attached to every frame so panels can tell how old
+// the forecast is, whether it has expired and whether it is stale cached
+// data that could not be refreshed
+type ForecastMeta struct {
+	Issued  time.Time `json:"issued"`
+	Expires time.Time `json:"expires"`
+	Expired bool      `json:"expired"`
+	Stale   bool      `json:"stale"`
+	Fetched time.Time `json:"fetched,omitempty"`
+}
+
+// forecastMeta describes the oldest forecast in the frame. Zero times are
+// forecasts without a published issue or expiry time and are ignored.
+func forecastMeta(issued, expires []time.Time) *data.FrameMeta {
+	fm := ForecastMeta{
+		Issued:  earliest(issued),
+		Expires: earliest(expires),
+	}
+	fm.Expired = !fm.Expires.IsZero() && time.Now().After(fm.Expires)
+
+	meta := &data.FrameMeta{Custom: fm}
+	if fm.Expired {
+		meta.Notices = append(meta.Notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprint("Forecast expired at ", fm.Expires.Format(time.RFC1123)),
+		})
+	}
+	return meta
+}
+
+func earliest(times []time.Time) time.Time {
+	var e time.Time
+	for _, t := range times {
+		if !t.IsZero() && (e.IsZero() || t.Before(e)) {
+			e = t
+		}
+	}
+	return e
+}
+
+func repeatTime(t time.Time, n int) []time.Time {
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = t
+	}
+	return times
+}
+
 func toInt(b bool) int32 {
 	if b {
 		return 1
@@ -3,11 +3,17 @@ package plugin_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/grafana/caic-datasource/pkg/archive"
 	"github.com/grafana/caic-datasource/pkg/caic"
 	"github.com/grafana/caic-datasource/pkg/plugin"
+	"github.com/grafana/caic-datasource/pkg/provider"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
 )
 
@@ -125,49 +131,574 @@ func TestQueryForZones(t *testing.T) {
 		require.Equal(t, "Zone 3", frame.At(0, 0).(string))
 	})
 
+	t.Run("it returns when each forecast was issued and expires", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		issued := time.Now().Add(-time.Hour).UTC()
+		expires := time.Now().Add(time.Hour).UTC()
+		client.zones <- []caic.Zone{{Index: 2, Name: "Zone 2", Rating: 3, Issued: issued, Expires: expires}}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":2}`),
+					},
+				},
+			},
+		)
+
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, "issued", frame.Fields[5].Name)
+		require.Equal(t, issued, frame.At(5, 0).(time.Time))
+
+		require.Equal(t, "expires", frame.Fields[6].Name)
+		require.Equal(t, expires, frame.At(6, 0).(time.Time))
+
+		meta := frame.Meta.Custom.(plugin.ForecastMeta)
+		require.Equal(t, issued, meta.Issued)
+		require.Equal(t, expires, meta.Expires)
+		require.False(t, meta.Expired)
+		require.Empty(t, frame.Meta.Notices)
+	})
+
+	t.Run("it flags an expired forecast", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		expired := time.Now().Add(-time.Hour)
+		client.zones <- []caic.Zone{
+			{Index: 2, Name: "Zone 2", Rating: 3, Expires: time.Now().Add(time.Hour)},
+			{Index: 3, Name: "Zone 3", Rating: 3, Expires: expired},
+		}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":-1}`),
+					},
+				},
+			},
+		)
+
+		frame := res.Responses["A"].Frames[0]
+		meta := frame.Meta.Custom.(plugin.ForecastMeta)
+		require.True(t, meta.Expired)
+		require.Equal(t, expired, meta.Expires)
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, frame.Meta.Notices[0].Severity)
+	})
+
+	t.Run("it returns tomorrow's outlook when asked", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{
+			{
+				Index:                 2,
+				Name:                  "Zone 2",
+				Rating:                4,
+				AboveTreeline:         4,
+				NearTreeline:          3,
+				BelowTreeline:         2,
+				TomorrowRating:        3,
+				TomorrowAboveTreeline: 3,
+				TomorrowNearTreeline:  2,
+				TomorrowBelowTreeline: 1,
+			},
+		}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":2, "day":"tomorrow"}`),
+					},
+				},
+			},
+		)
+
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, int64(3), frame.At(1, 0).(int64))
+		require.Equal(t, int64(3), frame.At(2, 0).(int64))
+		require.Equal(t, int64(2), frame.At(3, 0).(int64))
+		require.Equal(t, int64(1), frame.At(4, 0).(int64))
+
+		require.Equal(t, "day", frame.Fields[7].Name)
+		require.Equal(t, "tomorrow", frame.At(7, 0).(string))
+	})
+
+	t.Run("it returns a row for today and tomorrow when day is both", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{
+			{Index: 2, Name: "Zone 2", Rating: 4, TomorrowRating: 3},
+			{Index: 3, Name: "Zone 3", Rating: 2, TomorrowRating: 1},
+		}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":-1, "day":"both"}`),
+					},
+				},
+			},
+		)
+
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 4, frame.Rows())
+
+		expected := []struct {
+			name   string
+			rating int64
+			day    string
+		}{
+			{"Zone 2", 4, "today"},
+			{"Zone 2", 3, "tomorrow"},
+			{"Zone 3", 2, "today"},
+			{"Zone 3", 1, "tomorrow"},
+		}
+		for i, e := range expected {
+			require.Equal(t, e.name, frame.At(0, i).(string))
+			require.Equal(t, e.rating, frame.At(1, i).(int64))
+			require.Equal(t, e.day, frame.At(7, i).(string))
+		}
+	})
+
+	t.Run("it returns today's ratings when no day is given", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{{Index: 2, Name: "Zone 2", Rating: 4, TomorrowRating: 3}}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":2}`),
+					},
+				},
+			},
+		)
+
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, int64(4), frame.At(1, 0).(int64))
+		require.Equal(t, "today", frame.At(7, 0).(string))
+	})
+
+	t.Run("returns an error for an unknown day", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":2, "day":"yesterday"}`),
+					},
+				},
+			},
+		)
+
+		require.Nil(t, err)
+		require.Contains(t, res.Responses["A"].Error.Error(), "unknown day yesterday")
+	})
+
+	t.Run("returns an error for an unknown region", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+		client.zones <- []caic.Zone{{Index: 2, Name: "Zone 2", Rating: 3}}
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":42}`),
+					},
+					{
+						RefID: "B",
+						JSON:  []byte(`{"regions":[1,42]}`),
+					},
+					{
+						RefID: "C",
+						JSON:  []byte(`{"zone":2}`),
+					},
+				},
+			},
+		)
+
+		require.Nil(t, err)
+		require.EqualError(t, res.Responses["A"].Error, "bad query: unknown region 42")
+		require.EqualError(t, res.Responses["B"].Error, "bad query: unknown region 42")
+		require.Nil(t, res.Responses["C"].Error)
+	})
+
 	t.Run("return an error if it can't get zones", func(t *testing.T) {
 		h := &plugin.Handler{}
 		client := newFakeClient()
 
-		client.zones <- []caic.Zone{}
-		client.err = errors.New("something bad")
+		client.zones <- []caic.Zone{}
+		client.err = errors.New("something bad")
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":2}`),
+					},
+				},
+			},
+		)
+
+		require.Nil(t, err)
+		require.Contains(t, res.Responses["A"].Error.Error(), "something bad")
+	})
+
+	t.Run("returns returns an error if the request has bad json", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{{Index: 2, Name: "zone 2", Rating: 3}}
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone": "2"}`), //can't marshal into int
+					},
+				},
+			},
+		)
+
+		require.Nil(t, err)
+		require.Contains(t, res.Responses["A"].Error.Error(), "json: cannot unmarshal string into Go struct field .zone of type provider.ZoneID")
+	})
+}
+
+func TestQueryErrors(t *testing.T) {
+	t.Run("a failed region doesn't fail the other queries", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{{Index: 2, Name: "Zone 2", Rating: 3}}
+		client.zones <- []caic.Zone{{Index: 3, Name: "Zone 3", Rating: 2}}
+		client.regionErrs[caic.SawatchRange] = errors.New("sawatch is down")
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":2}`),
+					},
+					{
+						RefID: "B",
+						JSON:  []byte(`{"zone":3}`),
+					},
+				},
+			},
+		)
+		require.Nil(t, err)
+
+		require.Nil(t, res.Responses["A"].Error)
+		require.Equal(t, "Zone 2", res.Responses["A"].Frames[0].At(0, 0).(string))
+
+		require.Contains(t, res.Responses["B"].Error.Error(), "sawatch is down")
+		require.Empty(t, res.Responses["B"].Frames)
+	})
+
+	t.Run("a malformed query doesn't fail the other queries", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{{Index: 2, Name: "Zone 2", Rating: 3}}
+		client.zones <- []caic.Zone{{Index: 4, Name: "Zone 4", Rating: 1}}
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":2}`),
+					},
+					{
+						RefID: "B",
+						JSON:  []byte(`{"zone": "3"}`),
+					},
+					{
+						RefID: "C",
+						JSON:  []byte(`{"zone":4, "day":"someday"}`),
+					},
+					{
+						RefID: "D",
+						JSON:  []byte(`{"zone":4}`),
+					},
+				},
+			},
+		)
+		require.Nil(t, err)
+		require.Len(t, res.Responses, 4)
+
+		require.Nil(t, res.Responses["A"].Error)
+		require.Equal(t, "Zone 2", res.Responses["A"].Frames[0].At(0, 0).(string))
+
+		require.Contains(t, res.Responses["B"].Error.Error(), "bad query")
+		require.Contains(t, res.Responses["C"].Error.Error(), "unknown day someday")
+
+		require.Nil(t, res.Responses["D"].Error)
+		require.Equal(t, "Zone 4", res.Responses["D"].Frames[0].At(0, 0).(string))
+	})
+}
+
+func TestQueryPartialResults(t *testing.T) {
+	t.Run("it returns the regions that could be fetched with a warning for the rest", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{{Index: 0, Name: "zone 1", Rating: 1}}
+		client.summaryErr = &caic.PartialError{
+			Errors: []caic.RegionError{
+				{Region: caic.Aspen, Err: errors.New("aspen is down")},
+				{Region: caic.Gunnison, Err: errors.New("gunnison is down")},
+			},
+		}
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":-1}`),
+					},
+				},
+			},
+		)
+		require.Nil(t, err)
+
+		require.Nil(t, res.Responses["A"].Error)
+
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, "zone 1", frame.At(0, 0).(string))
+
+		require.Len(t, frame.Meta.Notices, 2)
+		require.Equal(t, data.NoticeSeverityWarning, frame.Meta.Notices[0].Severity)
+		require.Equal(t, "Aspen: aspen is down", frame.Meta.Notices[0].Text)
+		require.Equal(t, "Gunnison: gunnison is down", frame.Meta.Notices[1].Text)
+	})
+
+	t.Run("it fails the query when no regions could be fetched", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- nil
+		client.summaryErr = &caic.PartialError{
+			Errors: []caic.RegionError{{Region: caic.Aspen, Err: errors.New("aspen is down")}},
+		}
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":-1}`),
+					},
+				},
+			},
+		)
+		require.Nil(t, err)
+		require.Contains(t, res.Responses["A"].Error.Error(), "aspen is down")
+	})
+}
+
+func TestQueryStaleData(t *testing.T) {
+	t.Run("it marks stale cached data in the frame meta", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		fetched := time.Now().Add(-2 * time.Hour)
+		client.zones <- []caic.Zone{{Index: 1, Name: "Front Range", Rating: 3}}
+		client.err = &caic.StaleError{Fetched: fetched, Err: errors.New("caic is down")}
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":1}`),
+					},
+				},
+			},
+		)
+		require.Nil(t, err)
+		require.Nil(t, res.Responses["A"].Error)
+		require.Len(t, res.Responses["A"].Frames, 4)
+
+		for _, frame := range res.Responses["A"].Frames {
+			meta := frame.Meta.Custom.(plugin.ForecastMeta)
+			require.True(t, meta.Stale)
+			require.Equal(t, fetched, meta.Fetched)
+
+			require.Len(t, frame.Meta.Notices, 1)
+			require.Contains(t, frame.Meta.Notices[0].Text, "it could not be refreshed: caic is down")
+		}
+
+		require.Equal(t, "Front Range", res.Responses["A"].Frames[0].At(0, 0).(string))
+	})
+
+	t.Run("fresh data is not marked stale", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+		client.zones <- []caic.Zone{{Index: 1, Name: "Front Range", Rating: 3}}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":1}`),
+					},
+				},
+			},
+		)
+
+		meta := res.Responses["A"].Frames[0].Meta.Custom.(plugin.ForecastMeta)
+		require.False(t, meta.Stale)
+	})
+
+	t.Run("data waiting for its first refresh is not marked stale", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+		client.zones <- []caic.Zone{{Index: 1, Name: "Front Range", Rating: 3}}
+		client.err = &caic.StaleError{Fetched: time.Now().Add(-2 * time.Hour)}
+
+		h.Client = client
+		res, err := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":1}`),
+					},
+				},
+			},
+		)
+		require.Nil(t, err)
+		require.Nil(t, res.Responses["A"].Error)
+
+		for _, frame := range res.Responses["A"].Frames {
+			require.False(t, frame.Meta.Custom.(plugin.ForecastMeta).Stale)
+			require.Empty(t, frame.Meta.Notices)
+		}
+	})
+}
+
+func TestQueryParseErrors(t *testing.T) {
+	t.Run("it returns a clear query error when the forecast can't be parsed", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.regionErrs[caic.FrontRange] = &caic.ParseError{
+			Selector: "#NBtl_0",
+			Region:   caic.FrontRange,
+			Page:     "/caic/pub_bc_avo.php?zone_id=1",
+		}
 
 		h.Client = client
-		_, err := h.QueryData(
+		res, err := h.QueryData(
 			context.Background(),
 			&backend.QueryDataRequest{
 				Queries: []backend.DataQuery{
 					{
 						RefID: "A",
-						JSON:  []byte(`{"zone":2}`),
+						JSON:  []byte(`{"zone":1}`),
 					},
 				},
 			},
 		)
+		require.Nil(t, err)
+
+		queryErr := res.Responses["A"].Error
+		require.Contains(t, queryErr.Error(), "the CAIC forecast page could not be read")
+		require.Contains(t, queryErr.Error(), `"#NBtl_0" not found on /caic/pub_bc_avo.php?zone_id=1`)
 
-		require.Contains(t, err.Error(), "something bad")
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(queryErr, &parseErr))
 	})
 
-	t.Run("returns returns an error if the request has bad json", func(t *testing.T) {
+	t.Run("it says the products couldn't be read when they're the source", func(t *testing.T) {
 		h := &plugin.Handler{}
 		client := newFakeClient()
 
-		client.zones <- []caic.Zone{{Index: 2, Name: "zone 2", Rating: 3}}
+		client.regionErrs[caic.FrontRange] = &caic.ParseError{
+			Selector: caic.FrontRange.String(),
+			Region:   caic.FrontRange,
+			Page:     caic.ProductsPath,
+		}
 
 		h.Client = client
-		_, err := h.QueryData(
+		res, err := h.QueryData(
 			context.Background(),
 			&backend.QueryDataRequest{
 				Queries: []backend.DataQuery{
 					{
 						RefID: "A",
-						JSON:  []byte(`{"zone": "2"}`), //can't marshal into int
+						JSON:  []byte(`{"zone":1}`),
 					},
 				},
 			},
 		)
+		require.Nil(t, err)
 
-		require.Contains(t, err.Error(), "json: cannot unmarshal string into Go struct field .zone of type caic.Region")
+		queryErr := res.Responses["A"].Error
+		require.Contains(t, queryErr.Error(), "the CAIC forecast products could not be read")
+		require.NotContains(t, queryErr.Error(), "forecast page")
 	})
 }
 
@@ -236,6 +767,10 @@ func TestQueryForProblems(t *testing.T) {
 		for i := 0; i < frame.Fields[0].Len(); i++ {
 			require.Equal(t, expected[i], frame.Fields[4].At(i).(int32))
 		}
+
+		require.Equal(t, "issued", frame.Fields[5].Name)
+		require.Equal(t, "expires", frame.Fields[6].Name)
+		require.Equal(t, 8, frame.Fields[6].Len())
 	})
 
 	t.Run("it returns aspect dangers even if region is EntireState", func(t *testing.T) {
@@ -256,7 +791,7 @@ func TestQueryForProblems(t *testing.T) {
 			},
 		)
 
-		require.Len(t, res.Responses["A"].Frames, 2)
+		require.Len(t, res.Responses["A"].Frames, 4)
 
 		frame := res.Responses["A"].Frames[1]
 		require.Equal(t, "Zones", res.Responses["A"].Frames[0].Name)
@@ -267,6 +802,472 @@ func TestQueryForProblems(t *testing.T) {
 		require.Equal(t, "belowTreeline", frame.Fields[4].Name)
 	})
 }
+
+func TestQueryForAvalancheProblems(t *testing.T) {
+	t.Run("it returns a row for each avalanche problem", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{}
+		client.problems = []caic.AvalancheProblem{
+			{
+				Region:     caic.FrontRange,
+				Type:       "Persistent Slab",
+				Likelihood: "Likely",
+				MinSize:    "Small",
+				MaxSize:    "Large",
+				AboveTreeline: caic.OrdinalDanger{
+					North:     true,
+					NorthEast: true,
+				},
+				NearTreeline: caic.OrdinalDanger{
+					North: true,
+				},
+			},
+			{
+				Region:     caic.VailSummitCounty,
+				Type:       "Wind Slab",
+				Likelihood: "Possible",
+				MinSize:    "Small",
+				MaxSize:    "Small",
+				AboveTreeline: caic.OrdinalDanger{
+					East: true,
+				},
+			},
+		}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":-1}`),
+					},
+				},
+			},
+		)
+
+		frame := res.Responses["A"].Frames[2]
+		require.Equal(t, "Problems", frame.Name)
+		require.Equal(t, 2, frame.Rows())
+
+		require.Equal(t, "region", frame.Fields[0].Name)
+		require.Equal(t, "Front Range", frame.At(0, 0).(string))
+		require.Equal(t, "Vail & Summit County", frame.At(0, 1).(string))
+
+		require.Equal(t, "type", frame.Fields[1].Name)
+		require.Equal(t, "Persistent Slab", frame.At(1, 0).(string))
+		require.Equal(t, "Wind Slab", frame.At(1, 1).(string))
+
+		require.Equal(t, "likelihood", frame.Fields[2].Name)
+		require.Equal(t, "Likely", frame.At(2, 0).(string))
+
+		require.Equal(t, "minSize", frame.Fields[3].Name)
+		require.Equal(t, "Small", frame.At(3, 0).(string))
+
+		require.Equal(t, "maxSize", frame.Fields[4].Name)
+		require.Equal(t, "Large", frame.At(4, 0).(string))
+
+		require.Equal(t, "aboveTreeline", frame.Fields[5].Name)
+		require.Equal(t, "N,NE", frame.At(5, 0).(string))
+		require.Equal(t, "E", frame.At(5, 1).(string))
+
+		require.Equal(t, "nearTreeline", frame.Fields[6].Name)
+		require.Equal(t, "N", frame.At(6, 0).(string))
+
+		require.Equal(t, "belowTreeline", frame.Fields[7].Name)
+		require.Equal(t, "", frame.At(7, 0).(string))
+	})
+}
+
+func TestQueryForForecastText(t *testing.T) {
+	t.Run("it returns the forecaster's text as string fields", func(t *testing.T) {
+		h := &plugin.Handler{}
+		client := newFakeClient()
+
+		client.zones <- []caic.Zone{}
+		client.text = caic.ForecastText{
+			Region:         caic.Aspen,
+			IssuedBy:       "Jane Forecaster",
+			BottomLine:     "bottom line",
+			TravelAdvice:   "travel advice",
+			Discussion:     "discussion",
+			WeatherSummary: "weather summary",
+		}
+
+		h.Client = client
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":4}`),
+					},
+				},
+			},
+		)
+
+		frame := res.Responses["A"].Frames[3]
+		require.Equal(t, "ForecastText", frame.Name)
+		require.Equal(t, 1, frame.Rows())
+
+		expected := []struct {
+			name  string
+			value string
+		}{
+			{"region", "Aspen"},
+			{"issuedBy", "Jane Forecaster"},
+			{"bottomLine", "bottom line"},
+			{"travelAdvice", "travel advice"},
+			{"discussion", "discussion"},
+			{"weatherSummary", "weather summary"},
+		}
+		for i, e := range expected {
+			require.Equal(t, e.name, frame.Fields[i].Name)
+			require.Equal(t, e.value, frame.At(i, 0).(string))
+		}
+	})
+}
+
+func TestQueryTypes(t *testing.T) {
+	tests := []struct {
+		queryType string
+		frame     string
+	}{
+		{"summary", "Zones"},
+		{"aspectDanger", "AspectDanger"},
+		{"problems", "Problems"},
+		{"text", "ForecastText"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint("a ", tt.queryType, " query only returns the ", tt.frame, " frame"), func(t *testing.T) {
+			client := newFakeClient()
+			client.zones <- []caic.Zone{{Name: "Zone 1"}}
+
+			h := &plugin.Handler{Client: client}
+			res, _ := h.QueryData(
+				context.Background(),
+				&backend.QueryDataRequest{
+					Queries: []backend.DataQuery{
+						{
+							RefID:     "A",
+							QueryType: tt.queryType,
+							JSON:      []byte(`{"zone":1}`),
+						},
+					},
+				},
+			)
+
+			require.Nil(t, res.Responses["A"].Error)
+			require.Len(t, res.Responses["A"].Frames, 1)
+			require.Equal(t, tt.frame, res.Responses["A"].Frames[0].Name)
+		})
+	}
+
+	t.Run("a summary query can be a time series", func(t *testing.T) {
+		client := newFakeClient()
+		client.zones <- []caic.Zone{{Name: "Zone 1"}}
+
+		h := &plugin.Handler{Client: client}
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID:     "A",
+						QueryType: "summary",
+						JSON:      []byte(`{"zone":1,"format":"timeseries"}`),
+					},
+				},
+			},
+		)
+
+		require.Nil(t, res.Responses["A"].Error)
+		require.Equal(t, data.TimeSeriesTypeWide, res.Responses["A"].Frames[0].TimeSeriesSchema().Type)
+	})
+
+	t.Run("it fails for an unknown query type", func(t *testing.T) {
+		h := &plugin.Handler{Client: newFakeClient()}
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID:     "A",
+						QueryType: "weather",
+						JSON:      []byte(`{"zone":1}`),
+					},
+				},
+			},
+		)
+
+		require.EqualError(t, res.Responses["A"].Error, "bad query: unknown query type weather")
+	})
+}
+
+func TestQueryRegions(t *testing.T) {
+	regionQuery := func(h *plugin.Handler, queryType, query string) backend.DataResponse {
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID:     "A",
+						QueryType: queryType,
+						JSON:      []byte(query),
+					},
+				},
+			},
+		)
+		return res.Responses["A"]
+	}
+
+	newClient := func() *fakeCaicClient {
+		client := newFakeClient()
+		client.regionZones[caic.FrontRange] = []caic.Zone{{Name: "Front Range", Rating: 3}}
+		client.regionZones[caic.VailSummitCounty] = []caic.Zone{{Name: "Vail & Summit County", Rating: 2}}
+		client.regionZones[caic.SawatchRange] = []caic.Zone{{Name: "Sawatch Range", Rating: 1}}
+		return client
+	}
+
+	t.Run("it combines the zones of every region in order", func(t *testing.T) {
+		h := &plugin.Handler{Client: newClient()}
+		res := regionQuery(h, "summary", `{"regions":[1,2,3]}`)
+		require.Nil(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, "Front Range", frame.At(0, 0))
+		require.Equal(t, "Vail & Summit County", frame.At(0, 1))
+		require.Equal(t, "Sawatch Range", frame.At(0, 2))
+	})
+
+	t.Run("it combines the aspect danger of every region with a region column", func(t *testing.T) {
+		h := &plugin.Handler{Client: newClient()}
+		res := regionQuery(h, "aspectDanger", `{"regions":[1,3]}`)
+		require.Nil(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 16, frame.Rows())
+
+		region := frame.Fields[7]
+		require.Equal(t, "region", region.Name)
+		require.Equal(t, "Front Range", region.At(0))
+		require.Equal(t, "Sawatch Range", region.At(8))
+	})
+
+	t.Run("it returns a row of text for every region", func(t *testing.T) {
+		h := &plugin.Handler{Client: newClient()}
+		res := regionQuery(h, "text", `{"regions":[1,3]}`)
+		require.Nil(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, "Front Range", frame.At(0, 0))
+		require.Equal(t, "Sawatch Range", frame.At(0, 1))
+	})
+
+	t.Run("it shows the regions that could be fetched with a warning for the rest", func(t *testing.T) {
+		client := newClient()
+		client.regionErrs[caic.VailSummitCounty] = errors.New("boom")
+
+		h := &plugin.Handler{Client: client}
+		res := regionQuery(h, "summary", `{"regions":[1,2,3]}`)
+		require.Nil(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Contains(t, frame.Meta.Notices[0].Text, "Vail & Summit County: boom")
+	})
+
+	t.Run("it fails when no region could be fetched", func(t *testing.T) {
+		client := newClient()
+		client.regionErrs[caic.FrontRange] = errors.New("boom")
+		client.regionErrs[caic.SawatchRange] = errors.New("boom")
+
+		h := &plugin.Handler{Client: client}
+		res := regionQuery(h, "summary", `{"regions":[1,3]}`)
+		require.EqualError(t, res.Error, "unable to fetch 2 of 2 regions: Front Range: boom; Sawatch Range: boom")
+	})
+
+	t.Run("the entire state covers every other region", func(t *testing.T) {
+		client := newClient()
+		client.regionZones[caic.EntireState] = []caic.Zone{{Name: "Zone 1"}}
+
+		h := &plugin.Handler{Client: client}
+		res := regionQuery(h, "summary", `{"regions":[1,-1,3]}`)
+		require.Nil(t, res.Error)
+		require.Equal(t, 1, res.Frames[0].Rows())
+		require.Equal(t, "Zone 1", res.Frames[0].At(0, 0))
+	})
+}
+
+func TestQueryForHistory(t *testing.T) {
+	t.Run("it returns the archived forecasts over the time range", func(t *testing.T) {
+		issued := time.Date(2021, 4, 14, 16, 30, 0, 0, time.UTC)
+		a := &fakeArchive{
+			forecasts: []archive.Forecast{
+				{
+					Region:               caic.FrontRange,
+					Issued:               issued,
+					Expires:              issued.AddDate(0, 0, 1),
+					Rating:               3,
+					AboveTreeline:        3,
+					NearTreeline:         2,
+					BelowTreeline:        1,
+					AboveTreelineAspects: caic.OrdinalDanger{North: true, East: true},
+				},
+				{
+					Region:  caic.FrontRange,
+					Issued:  issued.AddDate(0, 0, 1),
+					Expires: issued.AddDate(0, 0, 2),
+					Rating:  2,
+				},
+			},
+		}
+
+		h := &plugin.Handler{Client: newFakeClient(), Archive: a}
+		tr := backend.TimeRange{From: issued, To: issued.AddDate(0, 0, 7)}
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID:     "A",
+						QueryType: "history",
+						JSON:      []byte(`{"zone":1}`),
+						TimeRange: tr,
+					},
+				},
+			},
+		)
+
+		require.Nil(t, res.Responses["A"].Error)
+		require.Equal(t, caic.FrontRange, a.region)
+		require.Equal(t, tr.From, a.from)
+		require.Equal(t, tr.To, a.to)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+
+		frame := frames[0]
+		require.Equal(t, "History", frame.Name)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, issued, frame.Fields[0].At(0))
+		require.Equal(t, "Front Range", frame.Fields[1].At(0))
+		require.Equal(t, int64(3), frame.Fields[2].At(0))
+		require.Equal(t, int64(2), frame.Fields[2].At(1))
+		require.Equal(t, "aboveTreelineAspects", frame.Fields[6].Name)
+		require.Equal(t, "N,E", frame.Fields[6].At(0))
+	})
+
+	t.Run("it fails without an archive", func(t *testing.T) {
+		h := &plugin.Handler{Client: newFakeClient()}
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID:     "A",
+						QueryType: "history",
+						JSON:      []byte(`{"zone":1}`),
+					},
+				},
+			},
+		)
+
+		require.NotNil(t, res.Responses["A"].Error)
+	})
+}
+
+func TestQueryCenters(t *testing.T) {
+	centerQuery := func(h *plugin.Handler, queryType, query string) backend.DataResponse {
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID:     "A",
+						QueryType: queryType,
+						JSON:      []byte(query),
+					},
+				},
+			},
+		)
+		return res.Responses["A"]
+	}
+
+	newHandler := func() (*plugin.Handler, *fakeCaicClient) {
+		caicClient := newFakeClient()
+		caicClient.regionZones[caic.FrontRange] = []caic.Zone{{Index: caic.FrontRange, Name: "Front Range", Rating: 3}}
+
+		h := &plugin.Handler{
+			Client: caicClient,
+			Providers: provider.NewRegistry(
+				provider.NewCAIC(caicClient),
+				&fakeProvider{id: "uac", zones: []string{"Salt Lake", "Ogden"}},
+			),
+		}
+		return h, caicClient
+	}
+
+	t.Run("queries without a center are for CAIC", func(t *testing.T) {
+		h, _ := newHandler()
+		res := centerQuery(h, "summary", `{"zone":1}`)
+		require.Nil(t, res.Error)
+		require.Equal(t, "Front Range", res.Frames[0].At(0, 0))
+	})
+
+	t.Run("it queries the selected center", func(t *testing.T) {
+		h, _ := newHandler()
+		res := centerQuery(h, "summary", `{"center":"uac","zone":1}`)
+		require.Nil(t, res.Error)
+		require.Equal(t, "Ogden", res.Frames[0].At(0, 0))
+	})
+
+	t.Run("it names regions by the center's zones", func(t *testing.T) {
+		h, _ := newHandler()
+		res := centerQuery(h, "text", `{"center":"uac","regions":[0,1]}`)
+		require.Nil(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, "Salt Lake", frame.At(0, 0))
+		require.Equal(t, "Ogden", frame.At(0, 1))
+	})
+
+	t.Run("it fails for a center that isn't enabled", func(t *testing.T) {
+		h, _ := newHandler()
+		res := centerQuery(h, "summary", `{"center":"sac","zone":1}`)
+		require.EqualError(t, res.Error, "bad query: center sac isn't enabled in the datasource settings")
+
+		h = &plugin.Handler{Client: newFakeClient()}
+		res = centerQuery(h, "summary", `{"center":"uac","zone":1}`)
+		require.NotNil(t, res.Error)
+	})
+
+	t.Run("history is only archived for CAIC", func(t *testing.T) {
+		h, _ := newHandler()
+		h.Archive = &fakeArchive{}
+		res := centerQuery(h, "history", `{"center":"uac","zone":1}`)
+		require.EqualError(t, res.Error, "bad query: history isn't archived for center uac")
+	})
+
+	t.Run("the health check fails when any center can't be reached", func(t *testing.T) {
+		h, caicClient := newHandler()
+		caicClient.canConnect = true
+
+		res, _ := h.CheckHealth(context.Background(), &backend.CheckHealthRequest{})
+		require.Equal(t, backend.HealthStatusError, res.Status)
+		require.Equal(t, "Error reaching UAC site", res.Message)
+	})
+}
+
 func TestCheckHealthHandler(t *testing.T) {
 	t.Run("HealthStatusOK when can connect", func(t *testing.T) {
 		h := &plugin.Handler{}
@@ -303,29 +1304,123 @@ func newFakeClient() *fakeCaicClient {
 	return &fakeCaicClient{
 		zones:        make(chan []caic.Zone, 10),
 		aspectDanger: caic.AspectDanger{},
+		regionErrs:   make(map[caic.Region]error),
+		regionZones:  make(map[caic.Region][]caic.Zone),
 	}
 }
 
 type fakeCaicClient struct {
 	canConnect   bool
 	aspectDanger caic.AspectDanger
+	problems     []caic.AvalancheProblem
+	text         caic.ForecastText
 	zones        chan []caic.Zone
 	err          error
+	summaryErr   error
+	regionErrs   map[caic.Region]error
+	regionZones  map[caic.Region][]caic.Zone
 }
 
-func (c *fakeCaicClient) CanConnect() bool {
+func (c *fakeCaicClient) CanConnect(context.Context) bool {
 	return c.canConnect
 }
 
-func (c *fakeCaicClient) Summary(r caic.Region) ([]caic.Zone, error) {
+func (c *fakeCaicClient) Summary(ctx context.Context, r caic.Region) ([]caic.Zone, error) {
+	if err, ok := c.regionErrs[r]; ok {
+		return nil, err
+	}
+	if z, ok := c.regionZones[r]; ok {
+		return z, c.err
+	}
+
 	select {
 	case z := <-c.zones:
+		if c.summaryErr != nil {
+			return z, c.summaryErr
+		}
 		return z, c.err
 	default:
 		panic("called without any responses setup")
 	}
 }
 
-func (c *fakeCaicClient) AspectDanger(caic.Region) (caic.AspectDanger, error) {
-	return c.aspectDanger, c.err
+func (c *fakeCaicClient) AspectDanger(_ context.Context, r caic.Region) (caic.AspectDanger, error) {
+	if err, ok := c.regionErrs[r]; ok {
+		return caic.AspectDanger{}, err
+	}
+
+	ad := c.aspectDanger
+	ad.Region = r
+	return ad, c.err
+}
+
+func (c *fakeCaicClient) Problems(context.Context, caic.Region) ([]caic.AvalancheProblem, error) {
+	return c.problems, c.err
+}
+
+func (c *fakeCaicClient) ForecastText(_ context.Context, r caic.Region) (caic.ForecastText, error) {
+	if err, ok := c.regionErrs[r]; ok {
+		return caic.ForecastText{}, err
+	}
+
+	text := c.text
+	text.Region = r
+	return text, c.err
+}
+
+// fakeProvider is a center that can't be reached, with a rating of 2 in
+// every zone
+type fakeProvider struct {
+	id    string
+	zones []string
+}
+
+func (p *fakeProvider) Center() provider.Center {
+	return provider.Center{ID: p.id, Name: strings.ToUpper(p.id), AllZones: "Everywhere"}
+}
+
+func (p *fakeProvider) Zones() []provider.Zone {
+	var zones []provider.Zone
+	for i, name := range p.zones {
+		zones = append(zones, provider.Zone{ID: provider.ZoneID(i), Name: name})
+	}
+	return zones
+}
+
+func (p *fakeProvider) CanConnect(context.Context) bool {
+	return false
+}
+
+func (p *fakeProvider) Summary(_ context.Context, id provider.ZoneID) ([]provider.Summary, error) {
+	var summaries []provider.Summary
+	for _, z := range p.Zones() {
+		if id == provider.AllZones || id == z.ID {
+			summaries = append(summaries, provider.Summary{Zone: z.ID, Name: z.Name, Rating: 2})
+		}
+	}
+	return summaries, nil
+}
+
+func (p *fakeProvider) AspectDanger(_ context.Context, id provider.ZoneID) (provider.AspectDanger, error) {
+	return provider.AspectDanger{Zone: id}, nil
+}
+
+func (p *fakeProvider) Problems(context.Context, provider.ZoneID) ([]provider.Problem, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) Text(_ context.Context, id provider.ZoneID) (provider.Text, error) {
+	return provider.Text{Zone: id}, nil
+}
+
+type fakeArchive struct {
+	forecasts []archive.Forecast
+	region    caic.Region
+	from      time.Time
+	to        time.Time
+}
+
+func (a *fakeArchive) History(r caic.Region, from, to time.Time) []archive.Forecast {
+	a.region, a.from, a.to = r, from, to
+	return a.forecasts
 }
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/caic-datasource/pkg/provider"
+)
+
+// queryRegions returns the regions a query asked for. Queries saved before
+// there was a list of regions have a single zone. AllZones already
+// covers every other region.
+func queryRegions(zone provider.ZoneID, regions []provider.ZoneID) []provider.ZoneID {
+	if len(regions) == 0 {
+		return []provider.ZoneID{zone}
+	}
+
+	var unique []provider.ZoneID
+	seen := make(map[provider.ZoneID]bool)
+	for _, r := range regions {
+		if r == provider.AllZones {
+			return []provider.ZoneID{provider.AllZones}
+		}
+		if !seen[r] {
+			seen[r] = true
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
+
+// checkRegions fails unless every region is one of the provider's zones or
+// all of them
+func checkRegions(p provider.Provider, regions []provider.ZoneID) error {
+	names := zoneNames(p)
+	for _, r := range regions {
+		if _, ok := names[r]; !ok {
+			return errors.New(fmt.Sprint("bad query: unknown region ", int(r)))
+		}
+	}
+	return nil
+}
+
+// fetchRegions calls fetch for every one of the provider's regions in
+// parallel, leaving it to fetch to keep the results. The errors are merged
+// by mergeErrors.
+func fetchRegions(p provider.Provider, regions []provider.ZoneID, fetch func(i int, r provider.ZoneID) error) error {
+	errs := make([]error, len(regions))
+
+	var wg sync.WaitGroup
+	for i, r := range regions {
+		wg.Add(1)
+		go func(i int, r provider.ZoneID) {
+			defer wg.Done()
+			errs[i] = fetch(i, r)
+		}(i, r)
+	}
+	wg.Wait()
+
+	return mergeErrors(zoneNames(p), regions, errs)
+}
+
+// mergeErrors combines the errors of fetching several regions the way a
+// provider combines all of its zones. Failed regions are a
+// *provider.PartialError, so the rest are still shown, and regions that
+// couldn't be refreshed are the oldest *provider.StaleError when nothing
+// failed. A single region's error is returned as it is.
+func mergeErrors(names map[provider.ZoneID]string, regions []provider.ZoneID, errs []error) error {
+	if len(regions) == 1 {
+		return errs[0]
+	}
+
+	var failed []provider.ZoneError
+	var stale []provider.ZoneError
+	var oldest *provider.StaleError
+	for i, err := range errs {
+		var partialErr *provider.PartialError
+		var staleErr *provider.StaleError
+		switch {
+		case err == nil:
+		case errors.As(err, &staleErr) && staleErr.Err == nil:
+			// Waiting for its first refresh, which hasn't failed
+		case errors.As(err, &partialErr):
+			failed = append(failed, partialErr.Errors...)
+		case errors.As(err, &staleErr):
+			stale = append(stale, provider.ZoneError{Zone: regions[i], Name: names[regions[i]], Err: err})
+			if oldest == nil || staleErr.Fetched.Before(oldest.Fetched) {
+				oldest = staleErr
+			}
+		default:
+			failed = append(failed, provider.ZoneError{Zone: regions[i], Name: names[regions[i]], Err: err})
+		}
+	}
+
+	if len(failed) > 0 {
+		// Stale regions are still worth a warning next to the failures
+		return &provider.PartialError{Errors: append(failed, stale...), Total: len(regions)}
+	}
+	if oldest != nil {
+		return oldest
+	}
+	return nil
+}
+
+// usable is whether results that came back with err can be shown
+func usable(err error) bool {
+	var partialErr *provider.PartialError
+	var staleErr *provider.StaleError
+	return err == nil || errors.As(err, &partialErr) || errors.As(err, &staleErr)
+}
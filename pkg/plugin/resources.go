@@ -0,0 +1,190 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/provider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+)
+
+// cacheStatus is implemented by clients that cache what they fetch
+type cacheStatus interface {
+	Status() []caic.EntryStatus
+}
+
+// Handles calls to the datasource's resources:
+//
+//	/centers           every center the datasource can query
+//	/regions           every region with its id, name and bounds
+//	/forecast/{region} everything parsed from a region's forecast
+//	/cache             what the cache holds
+//
+// The regions and forecasts are CAIC's unless another center is selected
+// with the center parameter.
+func (h *Handler) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return httpadapter.New(h.resources()).CallResource(ctx, req, sender)
+}
+
+func (h *Handler) resources() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/centers", get(h.centers))
+	mux.HandleFunc("/regions", get(h.regions))
+	mux.HandleFunc("/forecast/", get(h.forecast))
+	mux.HandleFunc("/cache", get(h.cache))
+	return mux
+}
+
+// get only allows GET requests through to the handler
+func get(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "only GET is allowed")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+type regionResource struct {
+	ID     provider.ZoneID `json:"id"`
+	Name   string          `json:"name"`
+	Bounds provider.Bounds `json:"bounds"`
+}
+
+func (h *Handler) centers(w http.ResponseWriter, _ *http.Request) {
+	centers := []provider.Center{}
+	for _, p := range h.providers() {
+		centers = append(centers, p.Center())
+	}
+	writeJSON(w, http.StatusOK, centers)
+}
+
+func (h *Handler) regions(w http.ResponseWriter, req *http.Request) {
+	p, ok := h.resourceProvider(w, req)
+	if !ok {
+		return
+	}
+
+	center := p.Center()
+	regions := []regionResource{{
+		ID:     provider.AllZones,
+		Name:   center.AllZones,
+		Bounds: center.Bounds,
+	}}
+	for _, z := range p.Zones() {
+		regions = append(regions, regionResource{
+			ID:     z.ID,
+			Name:   z.Name,
+			Bounds: z.Bounds,
+		})
+	}
+	writeJSON(w, http.StatusOK, regions)
+}
+
+// resourceProvider returns the provider for the center a request asked
+// for, writing an error if it isn't enabled
+func (h *Handler) resourceProvider(w http.ResponseWriter, req *http.Request) (provider.Provider, bool) {
+	center := req.URL.Query().Get("center")
+	p, err := h.provider(center)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprint("unknown center ", center))
+		return nil, false
+	}
+	return p, true
+}
+
+type forecastResource struct {
+	Region       provider.ZoneID       `json:"region"`
+	Name         string                `json:"name"`
+	Zones        []provider.Summary    `json:"zones"`
+	AspectDanger provider.AspectDanger `json:"aspectDanger"`
+	Problems     []provider.Problem    `json:"problems"`
+	Text         provider.Text         `json:"text"`
+
+	// Why some of the forecast may be missing or out of date
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (h *Handler) forecast(w http.ResponseWriter, req *http.Request) {
+	p, ok := h.resourceProvider(w, req)
+	if !ok {
+		return
+	}
+
+	names := zoneNames(p)
+	id, err := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/forecast/"))
+	r := provider.ZoneID(id)
+	if _, ok := names[r]; err != nil || !ok {
+		writeError(w, http.StatusNotFound, "unknown region")
+		return
+	}
+
+	ctx := req.Context()
+	f := forecastResource{Region: r, Name: names[r]}
+
+	// check keeps the warnings about soft errors and writes the rest
+	check := func(err error, results int) bool {
+		d, err := degraded(err, results)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return false
+		}
+		for _, n := range d.notices {
+			f.Warnings = append(f.Warnings, n.Text)
+		}
+		return true
+	}
+
+	f.Zones, err = p.Summary(ctx, r)
+	if !check(err, len(f.Zones)) {
+		return
+	}
+
+	f.AspectDanger, err = p.AspectDanger(ctx, r)
+	if !check(err, 1) {
+		return
+	}
+
+	f.Problems, err = p.Problems(ctx, r)
+	if !check(err, len(f.Problems)) {
+		return
+	}
+
+	f.Text, err = p.Text(ctx, r)
+	if !check(err, 1) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (h *Handler) cache(w http.ResponseWriter, _ *http.Request) {
+	c, ok := h.Client.(cacheStatus)
+	if !ok {
+		writeError(w, http.StatusNotFound, "forecasts aren't cached")
+		return
+	}
+
+	status := c.Status()
+	if status == nil {
+		status = []caic.EntryStatus{}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,205 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/plugin"
+	"github.com/grafana/caic-datasource/pkg/provider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func callResource(t *testing.T, h *plugin.Handler, method, path string) *backend.CallResourceResponse {
+	t.Helper()
+
+	// Grafana passes the path without the query string, and the URL with it
+	url := path
+	path = strings.SplitN(path, "?", 2)[0]
+
+	sender := &resourceSender{}
+	err := h.CallResource(
+		context.Background(),
+		&backend.CallResourceRequest{Method: method, Path: path, URL: url},
+		sender,
+	)
+	require.Nil(t, err)
+	require.NotNil(t, sender.resp)
+	return sender.resp
+}
+
+type resourceSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *resourceSender) Send(resp *backend.CallResourceResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestRegionsResource(t *testing.T) {
+	h := &plugin.Handler{Client: newFakeClient()}
+	resp := callResource(t, h, http.MethodGet, "regions")
+	require.Equal(t, http.StatusOK, resp.Status)
+
+	var regions []struct {
+		ID     caic.Region `json:"id"`
+		Name   string      `json:"name"`
+		Bounds caic.Bounds `json:"bounds"`
+	}
+	require.Nil(t, json.Unmarshal(resp.Body, &regions))
+	require.Len(t, regions, 11)
+
+	require.Equal(t, caic.EntireState, regions[0].ID)
+	require.Equal(t, "Front Range", regions[2].Name)
+	require.Equal(t, caic.FrontRange.Bounds(), regions[2].Bounds)
+	require.Greater(t, regions[2].Bounds.North, regions[2].Bounds.South)
+}
+
+func TestCentersResource(t *testing.T) {
+	uac := &fakeProvider{id: "uac", zones: []string{"Salt Lake", "Ogden"}}
+	h := &plugin.Handler{
+		Client:    newFakeClient(),
+		Providers: provider.NewRegistry(provider.NewCAIC(newFakeClient()), uac),
+	}
+
+	t.Run("it lists the centers", func(t *testing.T) {
+		resp := callResource(t, h, http.MethodGet, "centers")
+		require.Equal(t, http.StatusOK, resp.Status)
+
+		var centers []provider.Center
+		require.Nil(t, json.Unmarshal(resp.Body, &centers))
+		require.Len(t, centers, 2)
+		require.Equal(t, "caic", centers[0].ID)
+		require.Equal(t, "uac", centers[1].ID)
+	})
+
+	t.Run("it lists the regions of the selected center", func(t *testing.T) {
+		resp := callResource(t, h, http.MethodGet, "regions?center=uac")
+		require.Equal(t, http.StatusOK, resp.Status)
+
+		var regions []struct {
+			ID   caic.Region `json:"id"`
+			Name string      `json:"name"`
+		}
+		require.Nil(t, json.Unmarshal(resp.Body, &regions))
+		require.Len(t, regions, 3)
+		require.Equal(t, caic.EntireState, regions[0].ID)
+		require.Equal(t, "Everywhere", regions[0].Name)
+		require.Equal(t, "Ogden", regions[2].Name)
+	})
+
+	t.Run("it 404s for a center that isn't enabled", func(t *testing.T) {
+		resp := callResource(t, h, http.MethodGet, "regions?center=sac")
+		require.Equal(t, http.StatusNotFound, resp.Status)
+
+		resp = callResource(t, h, http.MethodGet, "forecast/1?center=sac")
+		require.Equal(t, http.StatusNotFound, resp.Status)
+	})
+
+	t.Run("it 404s for a region the center doesn't have", func(t *testing.T) {
+		resp := callResource(t, h, http.MethodGet, "forecast/5?center=uac")
+		require.Equal(t, http.StatusNotFound, resp.Status)
+	})
+}
+
+func TestForecastResource(t *testing.T) {
+	t.Run("it returns the whole forecast", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionZones[caic.Aspen] = []caic.Zone{{Name: "Aspen", Rating: 3}}
+		client.problems = []caic.AvalancheProblem{{Type: "Wind Slab"}}
+		client.text = caic.ForecastText{BottomLine: "bottom line"}
+
+		h := &plugin.Handler{Client: client}
+		resp := callResource(t, h, http.MethodGet, "forecast/4")
+		require.Equal(t, http.StatusOK, resp.Status)
+
+		var f struct {
+			Name     string
+			Zones    []caic.Zone
+			Problems []caic.AvalancheProblem
+			Text     caic.ForecastText
+			Warnings []string
+		}
+		require.Nil(t, json.Unmarshal(resp.Body, &f))
+		require.Equal(t, "Aspen", f.Name)
+		require.Equal(t, 3, f.Zones[0].Rating)
+		require.Equal(t, "Wind Slab", f.Problems[0].Type)
+		require.Equal(t, "bottom line", f.Text.BottomLine)
+		require.Empty(t, f.Warnings)
+	})
+
+	t.Run("it warns about stale data", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionZones[caic.Aspen] = []caic.Zone{{Name: "Aspen"}}
+		client.err = &caic.StaleError{Fetched: time.Now(), Err: errors.New("caic is down")}
+
+		h := &plugin.Handler{Client: client}
+		resp := callResource(t, h, http.MethodGet, "forecast/4")
+		require.Equal(t, http.StatusOK, resp.Status)
+
+		var f struct{ Warnings []string }
+		require.Nil(t, json.Unmarshal(resp.Body, &f))
+		require.Len(t, f.Warnings, 4)
+	})
+
+	t.Run("it fails when the forecast can't be fetched", func(t *testing.T) {
+		client := newFakeClient()
+		client.regionErrs[caic.Aspen] = errors.New("boom")
+
+		h := &plugin.Handler{Client: client}
+		resp := callResource(t, h, http.MethodGet, "forecast/4")
+		require.Equal(t, http.StatusBadGateway, resp.Status)
+		require.JSONEq(t, `{"error":"boom"}`, string(resp.Body))
+	})
+
+	t.Run("it fails for an unknown region", func(t *testing.T) {
+		h := &plugin.Handler{Client: newFakeClient()}
+		for _, path := range []string{"forecast/10", "forecast/-2", "forecast/aspen"} {
+			resp := callResource(t, h, http.MethodGet, path)
+			require.Equal(t, http.StatusNotFound, resp.Status, path)
+		}
+	})
+
+	t.Run("only GET is allowed", func(t *testing.T) {
+		h := &plugin.Handler{Client: newFakeClient()}
+		resp := callResource(t, h, http.MethodPost, "forecast/4")
+		require.Equal(t, http.StatusMethodNotAllowed, resp.Status)
+	})
+}
+
+func TestCacheResource(t *testing.T) {
+	t.Run("it returns the cache status", func(t *testing.T) {
+		h := &plugin.Handler{Client: &statusClient{
+			fakeCaicClient: newFakeClient(),
+			status:         []caic.EntryStatus{{Kind: "forecast", Region: caic.Aspen, Stale: true}},
+		}}
+		resp := callResource(t, h, http.MethodGet, "cache")
+		require.Equal(t, http.StatusOK, resp.Status)
+
+		var status []caic.EntryStatus
+		require.Nil(t, json.Unmarshal(resp.Body, &status))
+		require.Equal(t, []caic.EntryStatus{{Kind: "forecast", Region: caic.Aspen, Stale: true}}, status)
+	})
+
+	t.Run("it is not found without a cache", func(t *testing.T) {
+		h := &plugin.Handler{Client: newFakeClient()}
+		resp := callResource(t, h, http.MethodGet, "cache")
+		require.Equal(t, http.StatusNotFound, resp.Status)
+	})
+}
+
+type statusClient struct {
+	*fakeCaicClient
+	status []caic.EntryStatus
+}
+
+func (c *statusClient) Status() []caic.EntryStatus {
+	return c.status
+}
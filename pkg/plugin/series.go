@@ -0,0 +1,99 @@
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/provider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// The frame formats a query can return its ratings in
+const (
+	formatTable      = "table"
+	formatTimeSeries = "timeseries"
+	formatLong       = "long"
+)
+
+var formats = map[string]bool{
+	"":               true,
+	formatTable:      true,
+	formatTimeSeries: true,
+	formatLong:       true,
+}
+
+// The elevation bands of a series, in the order they're added
+var elevationBands = []string{"aboveTreeline", "nearTreeline", "belowTreeline"}
+
+// querySeries returns the zone ratings as a time series that can drive
+// alert rules, the only frame in the response. Each region and elevation
+// band is its own series, labelled region and elevation, and a day label
+// is added when both days are requested. Every point is at the end of the
+// query's time range, when alerts are evaluated.
+func (h *Handler) querySeries(ctx context.Context, q zoneQuery) backend.DataResponse {
+	zones, err := h.summaries(ctx, q.provider, q.regions)
+	d, err := degraded(err, len(zones))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	frame := longFrame(zones, q.days, q.timeRange.To)
+	if q.format == formatTimeSeries {
+		frame, err = toWide(frame)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	var issued, expires []time.Time
+	for _, z := range zones {
+		issued = append(issued, z.Issued)
+		expires = append(expires, z.Expires)
+	}
+	frame.Meta = forecastMeta(issued, expires)
+	d.apply(frame)
+
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// longFrame has a row for each zone, elevation band and day with the
+// danger rating at t
+func longFrame(zones []provider.Summary, days []string, t time.Time) *data.Frame {
+	var times []time.Time
+	var regions []string
+	var elevations []string
+	var dayNames []string
+	var danger []int64
+	for _, z := range zones {
+		for _, d := range days {
+			_, above, near, below := ratingsFor(z, d)
+			for i, rating := range []int{above, near, below} {
+				times = append(times, t)
+				regions = append(regions, z.Name)
+				elevations = append(elevations, elevationBands[i])
+				dayNames = append(dayNames, d)
+				danger = append(danger, int64(rating))
+			}
+		}
+	}
+
+	frame := data.NewFrame("Zones")
+	frame.Fields = append(frame.Fields, data.NewField("time", nil, times))
+	frame.Fields = append(frame.Fields, data.NewField("region", nil, regions))
+	frame.Fields = append(frame.Fields, data.NewField("elevation", nil, elevations))
+	if len(days) > 1 {
+		frame.Fields = append(frame.Fields, data.NewField("day", nil, dayNames))
+	}
+	frame.Fields = append(frame.Fields, data.NewField("danger", nil, danger))
+	return frame
+}
+
+// toWide converts a long frame to a series per region and elevation band,
+// with the rest of the string fields as labels
+func toWide(long *data.Frame) (*data.Frame, error) {
+	if long.Rows() == 0 {
+		return data.NewFrame(long.Name, data.NewField("time", nil, []time.Time{})), nil
+	}
+	return data.LongToWide(long, nil)
+}
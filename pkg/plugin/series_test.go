@@ -0,0 +1,143 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/plugin"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+var seriesZones = []caic.Zone{
+	{
+		Name:                  "Front Range",
+		Rating:                4,
+		AboveTreeline:         4,
+		NearTreeline:          3,
+		BelowTreeline:         2,
+		TomorrowRating:        3,
+		TomorrowAboveTreeline: 3,
+		TomorrowNearTreeline:  2,
+		TomorrowBelowTreeline: 1,
+	},
+	{
+		Name:          "Aspen",
+		Rating:        2,
+		AboveTreeline: 2,
+		NearTreeline:  2,
+		BelowTreeline: 1,
+	},
+}
+
+func querySeries(t *testing.T, query string) (backend.DataResponse, backend.TimeRange) {
+	t.Helper()
+
+	client := newFakeClient()
+	client.zones <- seriesZones
+	h := &plugin.Handler{Client: client}
+
+	tr := backend.TimeRange{
+		From: time.Date(2021, 4, 14, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2021, 4, 15, 0, 0, 0, 0, time.UTC),
+	}
+	res, _ := h.QueryData(
+		context.Background(),
+		&backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					JSON:      []byte(query),
+					TimeRange: tr,
+				},
+			},
+		},
+	)
+
+	require.Nil(t, res.Responses["A"].Error)
+	return res.Responses["A"], tr
+}
+
+func TestQueryForTimeSeries(t *testing.T) {
+	t.Run("the timeseries format is a wide series per region and elevation", func(t *testing.T) {
+		res, tr := querySeries(t, `{"zone":-1,"format":"timeseries"}`)
+		require.Len(t, res.Frames, 1)
+
+		frame := res.Frames[0]
+		require.Equal(t, data.TimeSeriesTypeWide, frame.TimeSeriesSchema().Type)
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, tr.To, frame.Fields[0].At(0))
+
+		// a time field and one field for each region and elevation
+		require.Len(t, frame.Fields, 7)
+
+		danger := map[string]int64{}
+		for _, f := range frame.Fields[1:] {
+			require.Len(t, f.Labels, 2)
+			danger[f.Labels["region"]+"/"+f.Labels["elevation"]] = f.At(0).(int64)
+		}
+		require.Equal(t, map[string]int64{
+			"Front Range/aboveTreeline": 4,
+			"Front Range/nearTreeline":  3,
+			"Front Range/belowTreeline": 2,
+			"Aspen/aboveTreeline":       2,
+			"Aspen/nearTreeline":        2,
+			"Aspen/belowTreeline":       1,
+		}, danger)
+	})
+
+	t.Run("the long format has a row per region and elevation", func(t *testing.T) {
+		res, tr := querySeries(t, `{"zone":-1,"format":"long"}`)
+		require.Len(t, res.Frames, 1)
+
+		frame := res.Frames[0]
+		require.Equal(t, data.TimeSeriesTypeLong, frame.TimeSeriesSchema().Type)
+		require.Equal(t, 6, frame.Rows())
+
+		var names []string
+		for _, f := range frame.Fields {
+			names = append(names, f.Name)
+		}
+		require.Equal(t, []string{"time", "region", "elevation", "danger"}, names)
+
+		require.Equal(t, tr.To, frame.At(0, 0))
+		require.Equal(t, "Front Range", frame.At(1, 0))
+		require.Equal(t, "aboveTreeline", frame.At(2, 0))
+		require.Equal(t, int64(4), frame.At(3, 0))
+	})
+
+	t.Run("both days add a day label", func(t *testing.T) {
+		res, _ := querySeries(t, `{"zone":-1,"format":"timeseries","day":"both"}`)
+
+		frame := res.Frames[0]
+		require.Len(t, frame.Fields, 13)
+
+		for _, f := range frame.Fields[1:] {
+			if f.Labels["region"] == "Front Range" && f.Labels["elevation"] == "aboveTreeline" && f.Labels["day"] == "tomorrow" {
+				require.Equal(t, int64(3), f.At(0))
+				return
+			}
+		}
+		require.Fail(t, "no series for tomorrow")
+	})
+
+	t.Run("it fails for an unknown format", func(t *testing.T) {
+		h := &plugin.Handler{Client: newFakeClient()}
+		res, _ := h.QueryData(
+			context.Background(),
+			&backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
+					{
+						RefID: "A",
+						JSON:  []byte(`{"zone":1,"format":"graph"}`),
+					},
+				},
+			},
+		)
+
+		require.EqualError(t, res.Responses["A"].Error, "bad query: unknown format graph")
+	})
+}
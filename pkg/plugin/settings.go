@@ -0,0 +1,161 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/provider"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Where forecasts are read from
+const (
+	SourceHTML = "html"
+	SourceJSON = "json"
+)
+
+// The API CAIC serves its forecast products from
+const defaultAPIURL = "https://avalanche.state.co.us/api-proxy/avid?_api_proxy_uri="
+
+// Settings are the options configured on each datasource
+type Settings struct {
+	// URL is the CAIC website forecast pages are scraped from. When it's
+	// empty, it's read from the CAIC_ADDR environment variable.
+	URL string `json:"url"`
+
+	// Timeout limits how long each attempt at a request to CAIC can take.
+	// Requests that time out are retried, and the cache gives each fetch
+	// long enough for every attempt.
+	Timeout time.Duration `json:"-"`
+
+	// CacheDuration caps how long a forecast is cached. The cache's
+	// default is used when it's zero.
+	CacheDuration time.Duration `json:"-"`
+
+	// MaxConcurrency limits how many regions are scraped at once for the
+	// entire state. The client's default is used when it's zero.
+	MaxConcurrency int `json:"maxConcurrency"`
+
+	// UserAgent is sent with every request to CAIC, caic.DefaultUserAgent
+	// by default
+	UserAgent string `json:"userAgent"`
+
+	// Source is whether forecasts are scraped from the forecast pages,
+	// SourceHTML, or read from CAIC's JSON forecast products, SourceJSON
+	Source string `json:"source"`
+
+	// APIURL is where the JSON forecast products are served from
+	APIURL string `json:"apiUrl"`
+
+	// CachePath is a directory the forecast cache is kept in so it
+	// survives restarts. The cache is only kept in memory when it's empty.
+	CachePath string `json:"cachePath"`
+
+	// ArchivePath is a directory every fetched forecast is archived in for
+	// history queries. History queries fail when it's empty.
+	ArchivePath string `json:"archivePath"`
+
+	// Centers are the IDs of the avalanche centers the datasource can
+	// query, only CAIC by default
+	Centers []string `json:"centers"`
+}
+
+// The attempt timeout when the settings don't have one, short enough that
+// a request that hangs is soon retried
+const defaultTimeout = 10 * time.Second
+
+// LoadSettings reads the datasource's settings, filling in defaults for
+// the ones that aren't set. Durations are strings like "30s" or "2h".
+func LoadSettings(s backend.DataSourceInstanceSettings) (Settings, error) {
+	settings := Settings{
+		Source:    SourceHTML,
+		APIURL:    defaultAPIURL,
+		Timeout:   defaultTimeout,
+		UserAgent: caic.DefaultUserAgent,
+		Centers:   []string{provider.CAICID},
+	}
+	if len(s.JSONData) == 0 {
+		return settings, nil
+	}
+
+	raw := struct {
+		Settings
+		Timeout       string `json:"timeout"`
+		CacheDuration string `json:"cacheDuration"`
+	}{Settings: settings}
+	if err := json.Unmarshal(s.JSONData, &raw); err != nil {
+		return Settings{}, fmt.Errorf("bad datasource settings: %w", err)
+	}
+	settings = raw.Settings
+
+	var err error
+	if settings.Timeout, err = parseDuration("timeout", raw.Timeout, defaultTimeout); err != nil {
+		return Settings{}, err
+	}
+	if settings.CacheDuration, err = parseDuration("cacheDuration", raw.CacheDuration, 0); err != nil {
+		return Settings{}, err
+	}
+
+	if settings.URL != "" {
+		if settings.URL, err = parseURL("url", settings.URL); err != nil {
+			return Settings{}, err
+		}
+	}
+
+	if settings.MaxConcurrency < 0 {
+		return Settings{}, errors.New("bad datasource settings: maxConcurrency can't be negative")
+	}
+
+	switch settings.Source {
+	case "":
+		settings.Source = SourceHTML
+	case SourceHTML, SourceJSON:
+	default:
+		return Settings{}, fmt.Errorf("bad datasource settings: unknown source %q", settings.Source)
+	}
+
+	if settings.APIURL == "" {
+		settings.APIURL = defaultAPIURL
+	}
+	if settings.APIURL, err = parseURL("apiUrl", settings.APIURL); err != nil {
+		return Settings{}, err
+	}
+	if settings.UserAgent == "" {
+		settings.UserAgent = caic.DefaultUserAgent
+	}
+	if len(settings.Centers) == 0 {
+		settings.Centers = []string{provider.CAICID}
+	}
+	return settings, nil
+}
+
+// parseURL checks the named setting is an http or https URL, returning it
+// without a trailing slash
+func parseURL(name, s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("bad datasource settings: %s %q isn't an http or https URL", name, s)
+	}
+	return strings.TrimSuffix(s, "/"), nil
+}
+
+// parseDuration parses the named setting, returning def when it's empty
+func parseDuration(name, s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad datasource settings: %s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("bad datasource settings: %s must be positive", name)
+	}
+	return d, nil
+}
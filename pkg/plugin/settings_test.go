@@ -0,0 +1,124 @@
+package plugin_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/plugin"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSettings(t *testing.T) {
+	t.Run("it reads the cache and archive paths", func(t *testing.T) {
+		settings, err := plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"cachePath": "/var/lib/grafana/caic", "archivePath": "/var/lib/grafana/caic-archive"}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, "/var/lib/grafana/caic", settings.CachePath)
+		require.Equal(t, "/var/lib/grafana/caic-archive", settings.ArchivePath)
+	})
+
+	t.Run("it reads the connection settings", func(t *testing.T) {
+		settings, err := plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{
+				"url": "http://caic.internal:8080/",
+				"timeout": "10s",
+				"cacheDuration": "1h",
+				"maxConcurrency": 2,
+				"userAgent": "avalanche-dashboard/1.0"
+			}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, "http://caic.internal:8080", settings.URL)
+		require.Equal(t, 10*time.Second, settings.Timeout)
+		require.Equal(t, time.Hour, settings.CacheDuration)
+		require.Equal(t, 2, settings.MaxConcurrency)
+		require.Equal(t, "avalanche-dashboard/1.0", settings.UserAgent)
+	})
+
+	t.Run("it defaults the connection settings", func(t *testing.T) {
+		for _, jsonData := range []string{"", `{}`, `{"timeout": ""}`} {
+			settings, err := plugin.LoadSettings(backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)})
+			require.Nil(t, err)
+			require.Equal(t, "", settings.URL)
+			require.Equal(t, 10*time.Second, settings.Timeout)
+			require.Equal(t, time.Duration(0), settings.CacheDuration)
+			require.Equal(t, 0, settings.MaxConcurrency)
+			require.Equal(t, caic.DefaultUserAgent, settings.UserAgent)
+		}
+	})
+
+	t.Run("it defaults to scraping the forecast pages", func(t *testing.T) {
+		settings, err := plugin.LoadSettings(backend.DataSourceInstanceSettings{})
+		require.Nil(t, err)
+		require.Equal(t, plugin.SourceHTML, settings.Source)
+		require.Equal(t, "", settings.CachePath)
+		require.Equal(t, []string{"caic"}, settings.Centers)
+
+		settings, err = plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"source": ""}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, plugin.SourceHTML, settings.Source)
+	})
+
+	t.Run("it reads the JSON source", func(t *testing.T) {
+		settings, err := plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"source": "json"}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, plugin.SourceJSON, settings.Source)
+		require.NotEmpty(t, settings.APIURL)
+
+		settings, err = plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"source": "json", "apiUrl": "http://localhost:3000"}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, "http://localhost:3000", settings.APIURL)
+
+		settings, err = plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"source": "json", "apiUrl": "http://localhost:3000/"}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, "http://localhost:3000", settings.APIURL)
+	})
+
+	t.Run("it reads the centers", func(t *testing.T) {
+		settings, err := plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"centers": ["caic", "uac"]}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, []string{"caic", "uac"}, settings.Centers)
+
+		settings, err = plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"centers": []}`),
+		})
+		require.Nil(t, err)
+		require.Equal(t, []string{"caic"}, settings.Centers)
+	})
+
+	t.Run("it returns an error for bad settings", func(t *testing.T) {
+		_, err := plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"cachePath": 1}`),
+		})
+		require.NotNil(t, err)
+
+		_, err = plugin.LoadSettings(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"source": "xml"}`),
+		})
+		require.EqualError(t, err, `bad datasource settings: unknown source "xml"`)
+
+		for jsonData, msg := range map[string]string{
+			`{"url": "caic.internal"}`:          `bad datasource settings: url "caic.internal" isn't an http or https URL`,
+			`{"apiUrl": "ftp://caic.internal"}`: `bad datasource settings: apiUrl "ftp://caic.internal" isn't an http or https URL`,
+			`{"timeout": "soon"}`:               `bad datasource settings: timeout: time: invalid duration "soon"`,
+			`{"cacheDuration": "-1h"}`:          "bad datasource settings: cacheDuration must be positive",
+			`{"maxConcurrency": -1}`:            "bad datasource settings: maxConcurrency can't be negative",
+		} {
+			_, err = plugin.LoadSettings(backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)})
+			require.EqualError(t, err, msg, jsonData)
+		}
+	})
+}
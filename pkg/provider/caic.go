@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+)
+
+// CAICID is the ID of the Colorado Avalanche Information Center, the
+// center queries are for when they don't name one
+const CAICID = "caic"
+
+// caicProvider fetches CAIC's forecasts, whose zones are the caic.Regions
+// with the same IDs
+type caicProvider struct {
+	fetcher caic.Fetcher
+}
+
+// NewCAIC returns a provider for CAIC that fetches forecasts with f, which
+// is usually a *caic.Cache
+func NewCAIC(f caic.Fetcher) Provider {
+	return caicProvider{f}
+}
+
+func (caicProvider) Center() Center {
+	return Center{
+		ID:       CAICID,
+		Name:     "CAIC",
+		Bounds:   caicBounds(caic.EntireState.Bounds()),
+		AllZones: caic.EntireState.String(),
+	}
+}
+
+func (caicProvider) Zones() []Zone {
+	var zones []Zone
+	for _, r := range caic.Regions() {
+		lat, lon := r.Centroid()
+		zones = append(zones, Zone{
+			ID:        ZoneID(r),
+			Name:      r.String(),
+			Bounds:    caicBounds(r.Bounds()),
+			Latitude:  lat,
+			Longitude: lon,
+		})
+	}
+	return zones
+}
+
+func (p caicProvider) CanConnect(ctx context.Context) bool {
+	return p.fetcher.CanConnect(ctx)
+}
+
+func (p caicProvider) Summary(ctx context.Context, id ZoneID) ([]Summary, error) {
+	zones, err := p.fetcher.Summary(ctx, caic.Region(id))
+
+	var summaries []Summary
+	for _, z := range zones {
+		summaries = append(summaries, Summary{
+			Zone:                  ZoneID(z.Index),
+			Name:                  z.Name,
+			Rating:                z.Rating,
+			AboveTreeline:         z.AboveTreeline,
+			NearTreeline:          z.NearTreeline,
+			BelowTreeline:         z.BelowTreeline,
+			TomorrowRating:        z.TomorrowRating,
+			TomorrowAboveTreeline: z.TomorrowAboveTreeline,
+			TomorrowNearTreeline:  z.TomorrowNearTreeline,
+			TomorrowBelowTreeline: z.TomorrowBelowTreeline,
+			Issued:                z.Issued,
+			Expires:               z.Expires,
+		})
+	}
+	return summaries, caicErr(err)
+}
+
+func (p caicProvider) AspectDanger(ctx context.Context, id ZoneID) (AspectDanger, error) {
+	ad, err := p.fetcher.AspectDanger(ctx, caic.Region(id))
+	return AspectDanger{
+		Zone:          ZoneID(ad.Region),
+		BelowTreeline: caicRose(ad.BelowTreeline),
+		NearTreeline:  caicRose(ad.NearTreeline),
+		AboveTreeline: caicRose(ad.AboveTreeline),
+		Issued:        ad.Issued,
+		Expires:       ad.Expires,
+	}, caicErr(err)
+}
+
+func (p caicProvider) Problems(ctx context.Context, id ZoneID) ([]Problem, error) {
+	problems, err := p.fetcher.Problems(ctx, caic.Region(id))
+
+	var converted []Problem
+	for _, ap := range problems {
+		converted = append(converted, Problem{
+			Zone:          ZoneID(ap.Region),
+			Type:          ap.Type,
+			Likelihood:    ap.Likelihood,
+			MinSize:       ap.MinSize,
+			MaxSize:       ap.MaxSize,
+			BelowTreeline: caicRose(ap.BelowTreeline),
+			NearTreeline:  caicRose(ap.NearTreeline),
+			AboveTreeline: caicRose(ap.AboveTreeline),
+			Issued:        ap.Issued,
+			Expires:       ap.Expires,
+		})
+	}
+	return converted, caicErr(err)
+}
+
+func (p caicProvider) Text(ctx context.Context, id ZoneID) (Text, error) {
+	text, err := p.fetcher.ForecastText(ctx, caic.Region(id))
+	return Text{
+		Zone:           ZoneID(text.Region),
+		IssuedBy:       text.IssuedBy,
+		BottomLine:     text.BottomLine,
+		TravelAdvice:   text.TravelAdvice,
+		Discussion:     text.Discussion,
+		WeatherSummary: text.WeatherSummary,
+		Issued:         text.Issued,
+		Expires:        text.Expires,
+	}, caicErr(err)
+}
+
+func caicBounds(b caic.Bounds) Bounds {
+	return Bounds{North: b.North, South: b.South, East: b.East, West: b.West}
+}
+
+func caicRose(od caic.OrdinalDanger) Rose {
+	return Rose{
+		North:     od.North,
+		NorthEast: od.NorthEast,
+		East:      od.East,
+		SouthEast: od.SouthEast,
+		South:     od.South,
+		SouthWest: od.SouthWest,
+		West:      od.West,
+		NorthWest: od.NorthWest,
+	}
+}
+
+// caicErr turns the cache's partial and stale results into the provider's,
+// and explains forecasts that couldn't be read. Other errors are returned
+// as they are.
+func caicErr(err error) error {
+	var partialErr *caic.PartialError
+	var staleErr *caic.StaleError
+	var parseErr *caic.ParseError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &partialErr):
+		total := partialErr.Total
+		if total == 0 {
+			total = len(caic.Regions())
+		}
+
+		converted := &PartialError{Total: total}
+		for _, re := range partialErr.Errors {
+			converted.Errors = append(converted.Errors, ZoneError{
+				Zone: ZoneID(re.Region),
+				Name: re.Region.String(),
+				Err:  re.Err,
+			})
+		}
+		return converted
+	case errors.As(err, &staleErr):
+		return &StaleError{Fetched: staleErr.Fetched, Err: staleErr.Err}
+	case errors.As(err, &parseErr) && parseErr.Page == caic.ProductsPath:
+		return fmt.Errorf("the CAIC forecast products could not be read, they may have changed or have no forecast for the zone: %w", err)
+	case errors.As(err, &parseErr):
+		return fmt.Errorf("the CAIC forecast page could not be read, it may have changed or have no forecast: %w", err)
+	}
+	return err
+}
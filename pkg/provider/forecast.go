@@ -0,0 +1,84 @@
+package provider
+
+import "time"
+
+// Summary is a zone's danger ratings
+type Summary struct {
+	Zone          ZoneID
+	Name          string
+	Rating        int
+	AboveTreeline int
+	NearTreeline  int
+	BelowTreeline int
+
+	// Tomorrow's outlook from the same forecast
+	TomorrowRating        int
+	TomorrowAboveTreeline int
+	TomorrowNearTreeline  int
+	TomorrowBelowTreeline int
+
+	Issued  time.Time
+	Expires time.Time
+}
+
+// AspectDanger is every aspect of each elevation band with a problem
+type AspectDanger struct {
+	Zone          ZoneID
+	BelowTreeline Rose
+	NearTreeline  Rose
+	AboveTreeline Rose
+	Issued        time.Time
+	Expires       time.Time
+}
+
+// Rose is whether each aspect of an elevation band is a danger
+type Rose struct {
+	North     bool
+	NorthEast bool
+	East      bool
+	SouthEast bool
+	South     bool
+	SouthWest bool
+	West      bool
+	NorthWest bool
+}
+
+var ordinals = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// Aspects returns the ordinals that are a danger, in compass order
+func (r Rose) Aspects() []string {
+	var aspects []string
+	for i, on := range []bool{r.North, r.NorthEast, r.East, r.SouthEast, r.South, r.SouthWest, r.West, r.NorthWest} {
+		if on {
+			aspects = append(aspects, ordinals[i])
+		}
+	}
+	return aspects
+}
+
+// Problem is a single avalanche problem from a zone's forecast along with
+// the aspects and elevations where it is found
+type Problem struct {
+	Zone          ZoneID
+	Type          string
+	Likelihood    string
+	MinSize       string
+	MaxSize       string
+	BelowTreeline Rose
+	NearTreeline  Rose
+	AboveTreeline Rose
+	Issued        time.Time
+	Expires       time.Time
+}
+
+// Text is the written part of a zone's forecast
+type Text struct {
+	Zone           ZoneID
+	IssuedBy       string
+	BottomLine     string
+	TravelAdvice   string
+	Discussion     string
+	WeatherSummary string
+	Issued         time.Time
+	Expires        time.Time
+}
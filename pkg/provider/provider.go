@@ -0,0 +1,165 @@
+// Package provider lets a datasource query avalanche centers other than
+// CAIC. Each center is a Provider, and the centers a datasource queries
+// are kept in a Registry.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ZoneID identifies one of a center's zones. IDs are only unique within
+// their center.
+type ZoneID int
+
+// AllZones stands for every zone of a center together
+const AllZones ZoneID = -1
+
+// Bounds is a box of latitudes and longitudes around an area
+type Bounds struct {
+	North float64 `json:"north"`
+	South float64 `json:"south"`
+	East  float64 `json:"east"`
+	West  float64 `json:"west"`
+}
+
+// Center is an avalanche center that publishes zone forecasts
+type Center struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Bounds Bounds `json:"bounds"` // the area the center's zones cover
+
+	// AllZones is what the center calls all of its zones together
+	AllZones string `json:"allZones"`
+}
+
+// Zone is one of a center's forecast zones
+type Zone struct {
+	ID     ZoneID `json:"id"`
+	Name   string `json:"name"`
+	Bounds Bounds `json:"bounds"`
+	// The zone's centroid
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Provider fetches a center's forecasts. Every zone can be fetched by its
+// ID or all of them at once with AllZones. Failing to fetch some of the
+// zones is a *PartialError and out of date forecasts are a *StaleError,
+// both returned along with the forecasts that could be fetched.
+type Provider interface {
+	Center() Center
+	// Zones returns every zone the center forecasts, in order
+	Zones() []Zone
+
+	CanConnect(ctx context.Context) bool
+	Summary(ctx context.Context, id ZoneID) ([]Summary, error)
+	AspectDanger(ctx context.Context, id ZoneID) (AspectDanger, error)
+	Problems(ctx context.Context, id ZoneID) ([]Problem, error)
+	Text(ctx context.Context, id ZoneID) (Text, error)
+}
+
+// ZoneError is the failure to fetch a single zone
+type ZoneError struct {
+	Zone ZoneID
+	Name string
+	Err  error
+}
+
+func (e ZoneError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Err)
+}
+
+func (e ZoneError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is returned along with the zones that were fetched when
+// some of them failed
+type PartialError struct {
+	Errors []ZoneError
+	Total  int // how many zones were fetched
+}
+
+func (e *PartialError) Error() string {
+	var msgs []string
+	for _, ze := range e.Errors {
+		msgs = append(msgs, ze.Error())
+	}
+	return fmt.Sprintf("unable to fetch %d of %d regions: %s", len(e.Errors), e.Total, strings.Join(msgs, "; "))
+}
+
+// StaleError is returned along with cached forecasts that are out of
+// date. Err is why they couldn't be refreshed, nil while the first
+// refresh hasn't finished.
+type StaleError struct {
+	Fetched time.Time
+	Err     error
+}
+
+func (e *StaleError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprint("data fetched at ", e.Fetched.Format(time.RFC3339), " is stale")
+	}
+	return fmt.Sprint("data fetched at ", e.Fetched.Format(time.RFC3339), " is stale: ", e.Err)
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// Registry holds the providers a datasource can query, by center ID
+type Registry struct {
+	providers map[string]Provider
+	ids       []string // in the order they were registered
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds the provider, replacing any provider already registered
+// for its center
+func (r *Registry) Register(p Provider) {
+	id := p.Center().ID
+	if _, ok := r.providers[id]; !ok {
+		r.ids = append(r.ids, id)
+	}
+	r.providers[id] = p
+}
+
+// Get returns the provider registered for the center
+func (r *Registry) Get(id string) (Provider, bool) {
+	p, ok := r.providers[id]
+	return p, ok
+}
+
+// Providers returns every registered provider, in the order they were
+// registered
+func (r *Registry) Providers() []Provider {
+	providers := make([]Provider, 0, len(r.ids))
+	for _, id := range r.ids {
+		providers = append(providers, r.providers[id])
+	}
+	return providers
+}
+
+// Select returns a registry of only the given centers, in the given
+// order. It fails if any of them isn't registered.
+func (r *Registry) Select(ids []string) (*Registry, error) {
+	selected := NewRegistry()
+	for _, id := range ids {
+		p, ok := r.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown center %q", id)
+		}
+		selected.Register(p)
+	}
+	return selected, nil
+}
@@ -0,0 +1,160 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/caic-datasource/pkg/caic"
+	"github.com/grafana/caic-datasource/pkg/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("it returns the providers in the order they were registered", func(t *testing.T) {
+		r := provider.NewRegistry(fakeProvider{id: "uac"}, provider.NewCAIC(nil))
+
+		p, ok := r.Get("caic")
+		require.True(t, ok)
+		require.Equal(t, "CAIC", p.Center().Name)
+
+		_, ok = r.Get("sac")
+		require.False(t, ok)
+
+		providers := r.Providers()
+		require.Len(t, providers, 2)
+		require.Equal(t, "uac", providers[0].Center().ID)
+		require.Equal(t, "caic", providers[1].Center().ID)
+	})
+
+	t.Run("it replaces a center's provider", func(t *testing.T) {
+		r := provider.NewRegistry(fakeProvider{id: "uac", name: "old"})
+		r.Register(fakeProvider{id: "uac", name: "new"})
+
+		providers := r.Providers()
+		require.Len(t, providers, 1)
+		require.Equal(t, "new", providers[0].Center().Name)
+	})
+
+	t.Run("it selects centers", func(t *testing.T) {
+		r := provider.NewRegistry(provider.NewCAIC(nil), fakeProvider{id: "uac"}, fakeProvider{id: "sac"})
+
+		selected, err := r.Select([]string{"sac", "caic"})
+		require.Nil(t, err)
+
+		providers := selected.Providers()
+		require.Len(t, providers, 2)
+		require.Equal(t, "sac", providers[0].Center().ID)
+		require.Equal(t, "caic", providers[1].Center().ID)
+
+		_, ok := selected.Get("uac")
+		require.False(t, ok)
+
+		_, err = r.Select([]string{"caic", "nwac"})
+		require.EqualError(t, err, `unknown center "nwac"`)
+	})
+}
+
+func TestCAIC(t *testing.T) {
+	p := provider.NewCAIC(nil)
+	require.Equal(t, bounds(caic.EntireState.Bounds()), p.Center().Bounds)
+	require.Equal(t, "Entire State", p.Center().AllZones)
+
+	zones := p.Zones()
+	require.Len(t, zones, len(caic.Regions()))
+
+	fr := zones[caic.FrontRange]
+	require.Equal(t, provider.ZoneID(caic.FrontRange), fr.ID)
+	require.Equal(t, "Front Range", fr.Name)
+	require.Equal(t, bounds(caic.FrontRange.Bounds()), fr.Bounds)
+
+	lat, lon := caic.FrontRange.Centroid()
+	require.Equal(t, lat, fr.Latitude)
+	require.Equal(t, lon, fr.Longitude)
+}
+
+func TestCAICForecasts(t *testing.T) {
+	t.Run("it converts the forecasts to the zone's ID", func(t *testing.T) {
+		p := provider.NewCAIC(&fakeFetcher{
+			zones: []caic.Zone{{Index: caic.Aspen, Name: "Aspen", Rating: 3}},
+			text:  caic.ForecastText{Region: caic.Aspen, BottomLine: "bottom line"},
+		})
+
+		summaries, err := p.Summary(context.Background(), provider.ZoneID(caic.Aspen))
+		require.Nil(t, err)
+		require.Equal(t, []provider.Summary{{Zone: provider.ZoneID(caic.Aspen), Name: "Aspen", Rating: 3}}, summaries)
+
+		text, err := p.Text(context.Background(), provider.ZoneID(caic.Aspen))
+		require.Nil(t, err)
+		require.Equal(t, provider.ZoneID(caic.Aspen), text.Zone)
+		require.Equal(t, "bottom line", text.BottomLine)
+	})
+
+	t.Run("it names the regions that failed", func(t *testing.T) {
+		p := provider.NewCAIC(&fakeFetcher{err: &caic.PartialError{
+			Errors: []caic.RegionError{{Region: caic.Aspen, Err: errors.New("boom")}},
+		}})
+
+		_, err := p.Summary(context.Background(), provider.AllZones)
+		var partialErr *provider.PartialError
+		require.True(t, errors.As(err, &partialErr))
+		require.Equal(t, len(caic.Regions()), partialErr.Total)
+		require.Equal(t, provider.ZoneID(caic.Aspen), partialErr.Errors[0].Zone)
+		require.Equal(t, "Aspen: boom", partialErr.Errors[0].Error())
+	})
+
+	t.Run("it keeps when stale data was fetched", func(t *testing.T) {
+		fetched := time.Now().Add(-time.Hour)
+		p := provider.NewCAIC(&fakeFetcher{err: &caic.StaleError{Fetched: fetched}})
+
+		_, err := p.Summary(context.Background(), provider.ZoneID(caic.Aspen))
+		var staleErr *provider.StaleError
+		require.True(t, errors.As(err, &staleErr))
+		require.Equal(t, fetched, staleErr.Fetched)
+		require.Nil(t, staleErr.Err)
+	})
+
+	t.Run("it explains forecasts that couldn't be read", func(t *testing.T) {
+		p := provider.NewCAIC(&fakeFetcher{err: &caic.ParseError{Region: caic.Aspen, Page: caic.ProductsPath}})
+
+		_, err := p.Summary(context.Background(), provider.ZoneID(caic.Aspen))
+		require.Contains(t, err.Error(), "the CAIC forecast products could not be read")
+
+		var parseErr *caic.ParseError
+		require.True(t, errors.As(err, &parseErr))
+	})
+}
+
+func bounds(b caic.Bounds) provider.Bounds {
+	return provider.Bounds{North: b.North, South: b.South, East: b.East, West: b.West}
+}
+
+type fakeProvider struct {
+	provider.Provider
+	id   string
+	name string
+}
+
+func (p fakeProvider) Center() provider.Center {
+	return provider.Center{ID: p.id, Name: p.name}
+}
+
+func (fakeProvider) Zones() []provider.Zone {
+	return nil
+}
+
+type fakeFetcher struct {
+	caic.Fetcher
+	zones []caic.Zone
+	text  caic.ForecastText
+	err   error
+}
+
+func (f *fakeFetcher) Summary(context.Context, caic.Region) ([]caic.Zone, error) {
+	return f.zones, f.err
+}
+
+func (f *fakeFetcher) ForecastText(context.Context, caic.Region) (caic.ForecastText, error) {
+	return f.text, f.err
+}
@@ -1,9 +1,179 @@
-import React from 'react';
-import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
-import { MyDataSourceOptions } from './types';
+import React, { ChangeEvent } from 'react';
+import { InlineFormLabel, LegacyForms, MultiSelect, Select } from '@grafana/ui';
+import { DataSourcePluginOptionsEditorProps, SelectableValue } from '@grafana/data';
+import { ForecastSource, MyDataSourceOptions } from './types';
+
+const { FormField } = LegacyForms;
 
 interface Props extends DataSourcePluginOptionsEditorProps<MyDataSourceOptions> {}
 
 export const ConfigEditor = (props: Props) => {
-  return <div></div>;
+  const { onOptionsChange, options } = props;
+
+  const sources: Array<SelectableValue<ForecastSource>> = [
+    { label: 'Forecast pages', value: 'html', description: 'scrape the forecast pages' },
+    { label: 'JSON products', value: 'json', description: "read CAIC's JSON forecast products" },
+  ];
+
+  // The avalanche centers the backend has a provider for
+  const centers: Array<SelectableValue<string>> = [{ label: 'CAIC', value: 'caic', description: 'Colorado' }];
+
+  const onCentersChange = (values: Array<SelectableValue<string>>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, centers: values.map((v) => v.value as string) } });
+  };
+
+  const onURLChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, url: event.target.value } });
+  };
+
+  const onTimeoutChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, timeout: event.target.value } });
+  };
+
+  const onCacheDurationChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, cacheDuration: event.target.value } });
+  };
+
+  const onMaxConcurrencyChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const maxConcurrency = parseInt(event.target.value, 10);
+    onOptionsChange({
+      ...options,
+      jsonData: { ...options.jsonData, maxConcurrency: isNaN(maxConcurrency) ? undefined : maxConcurrency },
+    });
+  };
+
+  const onUserAgentChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, userAgent: event.target.value } });
+  };
+
+  const onSourceChange = (value: SelectableValue<ForecastSource>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, source: value.value } });
+  };
+
+  const onAPIURLChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, apiUrl: event.target.value } });
+  };
+
+  const onCachePathChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, cachePath: event.target.value } });
+  };
+
+  const onArchivePathChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({ ...options, jsonData: { ...options.jsonData, archivePath: event.target.value } });
+  };
+
+  return (
+    <div className="gf-form-group">
+      <div className="gf-form">
+        <InlineFormLabel width={8} tooltip="the avalanche centers queries can select">
+          Centers
+        </InlineFormLabel>
+        <MultiSelect
+          width={24}
+          options={centers}
+          value={options.jsonData.centers && options.jsonData.centers.length > 0 ? options.jsonData.centers : ['caic']}
+          onChange={onCentersChange}
+        />
+      </div>
+      <div className="gf-form">
+        <InlineFormLabel width={8} tooltip="where forecasts are read from">
+          Source
+        </InlineFormLabel>
+        <Select width={24} options={sources} value={options.jsonData.source || 'html'} onChange={onSourceChange} />
+      </div>
+      {options.jsonData.source === 'json' && (
+        <div className="gf-form">
+          <FormField
+            label="API URL"
+            labelWidth={8}
+            inputWidth={24}
+            onChange={onAPIURLChange}
+            value={options.jsonData.apiUrl || ''}
+            placeholder="https://avalanche.state.co.us/api-proxy/avid?_api_proxy_uri="
+            tooltip="Where the forecast products are served from. Leave empty for CAIC's"
+          />
+        </div>
+      )}
+      {options.jsonData.source !== 'json' && (
+        <div className="gf-form">
+          <FormField
+            label="URL"
+            labelWidth={8}
+            inputWidth={24}
+            onChange={onURLChange}
+            value={options.jsonData.url || ''}
+            placeholder="https://www.avalanche.state.co.us"
+            tooltip="The CAIC website the forecast pages are scraped from. Leave empty for CAIC_ADDR or CAIC's"
+          />
+        </div>
+      )}
+      <div className="gf-form">
+        <FormField
+          label="Timeout"
+          labelWidth={8}
+          inputWidth={24}
+          onChange={onTimeoutChange}
+          value={options.jsonData.timeout || ''}
+          placeholder="10s"
+          tooltip="How long each attempt at a request to CAIC can take, failed attempts are retried"
+        />
+      </div>
+      <div className="gf-form">
+        <FormField
+          label="Cache Duration"
+          labelWidth={8}
+          inputWidth={24}
+          onChange={onCacheDurationChange}
+          value={options.jsonData.cacheDuration || ''}
+          placeholder="1h"
+          tooltip="The longest a forecast is cached, however long it's good for"
+        />
+      </div>
+      <div className="gf-form">
+        <FormField
+          label="Max Concurrency"
+          labelWidth={8}
+          inputWidth={24}
+          type="number"
+          onChange={onMaxConcurrencyChange}
+          value={options.jsonData.maxConcurrency || ''}
+          placeholder="4"
+          tooltip="How many regions are scraped at once for the entire state"
+        />
+      </div>
+      <div className="gf-form">
+        <FormField
+          label="User Agent"
+          labelWidth={8}
+          inputWidth={24}
+          onChange={onUserAgentChange}
+          value={options.jsonData.userAgent || ''}
+          placeholder="grafana-caic-datasource"
+          tooltip="Sent with every request to CAIC. Leave empty to identify the plugin"
+        />
+      </div>
+      <div className="gf-form">
+        <FormField
+          label="Cache Path"
+          labelWidth={8}
+          inputWidth={24}
+          onChange={onCachePathChange}
+          value={options.jsonData.cachePath || ''}
+          placeholder="directory to keep forecasts in across restarts"
+          tooltip="Leave empty to only cache forecasts in memory"
+        />
+      </div>
+      <div className="gf-form">
+        <FormField
+          label="Archive Path"
+          labelWidth={8}
+          inputWidth={24}
+          onChange={onArchivePathChange}
+          value={options.jsonData.archivePath || ''}
+          placeholder="directory to archive every forecast in"
+          tooltip="Needed for history queries. Leave empty to not keep past forecasts"
+        />
+      </div>
+    </div>
+  );
 };
@@ -1,14 +1,15 @@
 import defaults from 'lodash/defaults';
-import React from 'react';
-import { InlineFormLabel, Select } from '@grafana/ui';
+import React, { useEffect, useState } from 'react';
+import { InlineFormLabel, MultiSelect, Select } from '@grafana/ui';
 import { QueryEditorProps, SelectableValue } from '@grafana/data';
 import { DataSource } from './datasource';
-import { defaultQuery, MyDataSourceOptions, Region, ZoneQuery } from './types';
+import { defaultQuery, ForecastDay, MyDataSourceOptions, QueryFormat, QueryType, Region, ZoneQuery } from './types';
 
 type Props = QueryEditorProps<DataSource, ZoneQuery, MyDataSourceOptions>;
 
 export const QueryEditor = (props: Props) => {
-  const zones: Array<SelectableValue<number>> = [
+  // Used until the backend has listed its regions
+  const defaultZones: Array<SelectableValue<number>> = [
     { label: 'Entire State', value: Region.EntireState },
     { label: 'Steamboat & Flat Tops', value: Region.SteamboatFlatTops },
     { label: 'Front Range', value: Region.FrontRange },
@@ -21,22 +22,105 @@ export const QueryEditor = (props: Props) => {
     { label: 'Southern San Juan', value: Region.SouthernSanJuan },
     { label: 'Sangre de Cristo', value: Region.SangreDeCristo },
   ];
+  const [zones, setZones] = useState(defaultZones);
+  const [centers, setCenters] = useState<Array<SelectableValue<string>>>([{ label: 'CAIC', value: 'caic' }]);
 
-  const onRegionChange = (value: SelectableValue<number>) => {
+  const { datasource } = props;
+  const center = props.query.center;
+  useEffect(() => {
+    datasource
+      .getCenters()
+      .then((cs) => setCenters(cs.map((c) => ({ label: c.name, value: c.id }))))
+      .catch(() => {});
+  }, [datasource]);
+
+  useEffect(() => {
+    datasource
+      .getRegions(center)
+      .then((regions) => setZones(regions.map((r) => ({ label: r.name, value: r.id }))))
+      .catch(() => setZones(defaultZones));
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [datasource, center]);
+
+  const days: Array<SelectableValue<ForecastDay>> = [
+    { label: 'Today', value: 'today' },
+    { label: 'Tomorrow', value: 'tomorrow' },
+    { label: 'Both', value: 'both' },
+  ];
+
+  const queryTypes: Array<SelectableValue<QueryType>> = [
+    { label: 'Danger Ratings', value: 'summary' },
+    { label: 'Aspect Danger', value: 'aspectDanger' },
+    { label: 'Avalanche Problems', value: 'problems' },
+    { label: 'Forecast Text', value: 'text' },
+    { label: 'History', value: 'history', description: "archived ratings over the dashboard's time range" },
+    { label: 'Map', value: 'geo', description: "ratings at each zone's approximate centroid for the Geomap panel" },
+  ];
+
+  const formats: Array<SelectableValue<QueryFormat>> = [
+    { label: 'Table', value: 'table' },
+    { label: 'Time series', value: 'timeseries', description: 'a series per region and elevation, for alerting' },
+    { label: 'Long', value: 'long', description: 'a row per region and elevation' },
+  ];
+
+  const onCenterChange = (value: SelectableValue<string>) => {
+    const { onChange, query, onRunQuery } = props;
+    // Region ids are only unique within a center
+    onChange({ ...query, center: value.value, zone: Region.EntireState, regions: [] });
+    onRunQuery();
+  };
+
+  const onRegionsChange = (values: Array<SelectableValue<number>>) => {
+    const { onChange, query, onRunQuery } = props;
+    onChange({ ...query, regions: values.map((v) => v.value as Region) });
+    onRunQuery();
+  };
+
+  const onDayChange = (value: SelectableValue<ForecastDay>) => {
+    const { onChange, query, onRunQuery } = props;
+    onChange({ ...query, day: value.value });
+    onRunQuery();
+  };
+  const onQueryTypeChange = (value: SelectableValue<QueryType>) => {
+    const { onChange, query, onRunQuery } = props;
+    onChange({ ...query, queryType: value.value });
+    onRunQuery();
+  };
+
+  const onFormatChange = (value: SelectableValue<QueryFormat>) => {
     const { onChange, query, onRunQuery } = props;
-    onChange({ ...query, zone: value.value });
+    onChange({ ...query, format: value.value });
     onRunQuery();
   };
+
   const query = defaults(props.query, defaultQuery);
-  const { zone } = query;
+  const { zone, regions, day, queryType, format } = query;
+  const selectedRegions = regions && regions.length > 0 ? regions : [zone];
+  const isSummary = !queryType || queryType === 'summary';
 
   return (
     <div className="gf-form">
       <div className="gf-form-inline">
-        <InlineFormLabel width={12} className="zone-label" tooltip="select a geographic zone">
-          Select a Geographic Zone
+        <InlineFormLabel width={6} tooltip="the avalanche center that publishes the forecast">
+          Center
+        </InlineFormLabel>
+        <Select width={12} options={centers} value={center || 'caic'} onChange={onCenterChange} />
+        <InlineFormLabel width={12} className="zone-label" tooltip="select one or more geographic zones">
+          Select Geographic Zones
+        </InlineFormLabel>
+        <MultiSelect width={40} options={zones} value={selectedRegions} onChange={onRegionsChange} />
+        <InlineFormLabel width={6} tooltip="today's ratings, tomorrow's outlook or both">
+          Day
+        </InlineFormLabel>
+        <Select width={16} options={days} value={day} onChange={onDayChange} disabled={!isSummary && queryType !== 'geo'} />
+        <InlineFormLabel width={6} tooltip="which part of the forecast the query returns">
+          Query
+        </InlineFormLabel>
+        <Select width={20} options={queryTypes} value={queryType} onChange={onQueryTypeChange} />
+        <InlineFormLabel width={6} tooltip="how the current ratings are returned">
+          Format
         </InlineFormLabel>
-        <Select width={30} options={zones} value={zone} onChange={onRegionChange} />
+        <Select width={16} options={formats} value={format} onChange={onFormatChange} disabled={!isSummary} />
         <div className="gf-form gf-form--grow">
           <div className="gf-form-label gf-form-label--grow" />
         </div>
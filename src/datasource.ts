@@ -1,9 +1,17 @@
 import { DataSourceInstanceSettings } from '@grafana/data';
 import { DataSourceWithBackend } from '@grafana/runtime';
-import { MyDataSourceOptions, ZoneQuery } from './types';
+import { Center, MyDataSourceOptions, RegionInfo, ZoneQuery } from './types';
 
 export class DataSource extends DataSourceWithBackend<ZoneQuery, MyDataSourceOptions> {
   constructor(instanceSettings: DataSourceInstanceSettings<MyDataSourceOptions>) {
     super(instanceSettings);
   }
+
+  getCenters(): Promise<Center[]> {
+    return this.getResource('centers');
+  }
+
+  getRegions(center?: string): Promise<RegionInfo[]> {
+    return this.getResource('regions', center ? { center } : undefined);
+  }
 }
@@ -14,12 +14,54 @@ export enum Region {
   SangreDeCristo,
 }
 
+export interface Bounds {
+  north: number;
+  south: number;
+  east: number;
+  west: number;
+}
+
+// RegionInfo is a region as described by the backend's regions resource
+export interface RegionInfo {
+  id: Region;
+  name: string;
+  bounds: Bounds;
+}
+
+// Center is an avalanche center as described by the backend's centers
+// resource
+export interface Center {
+  id: string;
+  name: string;
+  bounds: Bounds;
+  // what the center calls all of its zones together, like Entire State
+  allZones: string;
+}
+
+export type ForecastSource = 'html' | 'json';
+
+export type ForecastDay = 'today' | 'tomorrow' | 'both';
+
+export type QueryFormat = 'table' | 'timeseries' | 'long';
+
+export type QueryType = 'summary' | 'aspectDanger' | 'problems' | 'text' | 'history' | 'geo';
+
 export interface ZoneQuery extends DataQuery {
+  // center is the avalanche center the query is for, CAIC when unset
+  center?: string;
+  // zone is the single region of queries saved before there was a list
   zone?: Region;
+  regions?: Region[];
+  day?: ForecastDay;
+  queryType?: QueryType;
+  format?: QueryFormat;
 }
 
 export const defaultQuery: Partial<ZoneQuery> = {
   zone: Region.EntireState,
+  day: 'today',
+  queryType: 'summary',
+  format: 'table',
 };
 
 /**
@@ -27,6 +69,17 @@ export const defaultQuery: Partial<ZoneQuery> = {
  */
 export interface MyDataSourceOptions extends DataSourceJsonData {
   path?: string;
+  url?: string;
+  // durations like '30s' or '2h'
+  timeout?: string;
+  cacheDuration?: string;
+  maxConcurrency?: number;
+  userAgent?: string;
+  source?: ForecastSource;
+  apiUrl?: string;
+  cachePath?: string;
+  archivePath?: string;
+  centers?: string[];
 }
 
 /**